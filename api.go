@@ -2,43 +2,140 @@ package tgx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
+	"slices"
 	"time"
 )
 
+const defaultAPIURL = "https://api.telegram.org"
+
 var client = &http.Client{
 	Timeout: 30 * time.Second,
 }
 
 type TelegramResponse struct {
-	Ok          bool            `json:"ok"`
-	Result      json.RawMessage `json:"result"`
-	Description string          `json:"description"`
-	ErrorCode   int             `json:"error_code"`
+	Ok          bool               `json:"ok"`
+	Result      json.RawMessage    `json:"result"`
+	Description string             `json:"description"`
+	ErrorCode   int                `json:"error_code"`
+	Parameters  ResponseParameters `json:"parameters"`
 }
 
 func (ctx *Context) makeRequest(method string, params map[string]interface{}) error {
-	return makeAPIRequest(ctx.bot.token, method, params)
+	// a call held for the webhook response has no result to report
+	if ctx.webhookReply.hold(method, params) {
+		return nil
+	}
+	_, err := ctx.bot.callAPI(ctx.Context(), method, params)
+	return err
 }
 func (ctx *CallbackContext) makeRequest(method string, params map[string]interface{}) error {
-	return makeAPIRequest(ctx.bot.token, method, params)
+	if ctx.webhookReply.hold(method, params) {
+		return nil
+	}
+	_, err := ctx.bot.callAPI(ctx.Context(), method, params)
+	return err
+}
+
+func (b *Bot) methodURL(method string) string {
+	return fmt.Sprintf("%s/bot%s/%s", b.apiURL, b.token, method)
 }
 
-func makeAPIRequest(token, method string, params map[string]interface{}) error {
-	_, err := makeAPIRequestWithResult(token, method, params)
+func (b *Bot) makeAPIRequest(method string, params map[string]interface{}) error {
+	_, err := b.makeAPIRequestWithResult(method, params)
 	return err
 }
 
-func makeAPIRequestWithResult(token, method string, params map[string]interface{}) (json.RawMessage, error) {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", token, method)
+// WithContext returns a bot making its calls in ctx. They are traced as
+// children of the span in ctx, and made from a handler with the context of
+// its update, they keep their order after a call held for the webhook
+// response:
+//
+//	bot.WithContext(ctx.Context()).SendMessage(chatID, "done")
+//
+// The returned bot shares its handlers and settings with b, it is meant for
+// making calls only.
+func (b *Bot) WithContext(ctx context.Context) *Bot {
+	c := *b
+	c.ctx = ctx
+	return &c
+}
+
+// context returns the context the bot's calls are made in.
+func (b *Bot) context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
+func (b *Bot) makeAPIRequestWithResult(method string, params map[string]interface{}) (json.RawMessage, error) {
+	ctx := b.context()
+	webhookReplyFrom(ctx).release()
+	return b.callAPI(ctx, method, params)
+}
+
+// callAPI makes a Bot API call as part of the update handled in ctx, if any,
+// and reports it to the bot's tracer, metrics, recorder and logger.
+func (b *Bot) callAPI(ctx context.Context, method string, params map[string]interface{}) (json.RawMessage, error) {
+	ctx, span := b.tracer.Start(ctx, SpanAPICall,
+		Attr(AttrMethod, method),
+		Attr(AttrChatID, params["chat_id"]),
+	)
+	defer span.End()
+
+	result, err := b.sendAPICall(ctx, method, params)
+	if newChatID, ok := MigratedChatID(err); ok {
+		if oldChatID, ok := chatIDParam(params); ok {
+			b.chatMigrated(oldChatID, newChatID)
+			switch {
+			case !b.retryMigratedChats:
+			case !replayable(params):
+				b.logger.Warn("Can't retry call to migrated chat, its upload was consumed", "method", method, "chat_id", oldChatID)
+			default:
+				b.metrics.APIRetry(method)
+				span.SetAttributes(Attr(AttrRetryCount, 1))
+				result, err = b.sendAPICall(ctx, method, withChatID(params, newChatID))
+			}
+		}
+	}
+
+	span.SetAttributes(Attr(AttrStatus, callStatus(err)))
+	if err != nil {
+		span.RecordError(err)
+	}
+	return result, err
+}
 
-	body, err := json.Marshal(params)
+// sendAPICall sends one attempt of a call through the transport and reports
+// it to the bot's metrics, recorder and logger.
+func (b *Bot) sendAPICall(ctx context.Context, method string, params map[string]interface{}) (json.RawMessage, error) {
+	start := time.Now()
+	result, err := b.roundTrip(ctx, &APICall{
+		Method: method,
+		Params: params,
+		Header: make(http.Header),
+	})
+	b.recordCall(method, params, result, err, false)
+	b.metrics.APICall(method, callStatus(err), time.Since(start))
+	if err != nil {
+		b.logger.Debug("API call failed", "method", method, "chat_id", params["chat_id"], "duration", time.Since(start), "error", err)
+	} else {
+		b.logger.Debug("API call", "method", method, "chat_id", params["chat_id"], "duration", time.Since(start))
+	}
+	return result, err
+}
+
+func (b *Bot) doAPIRequest(ctx context.Context, call *APICall) (json.RawMessage, error) {
+	url := b.methodURL(call.Method)
+
+	body, contentType, err := encodeParams(call.Params)
 	if err != nil {
 		return nil, &BotError{
 			Code:    http.StatusInternalServerError,
@@ -47,8 +144,11 @@ func makeAPIRequestWithResult(token, method string, params map[string]interface{
 		}
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
 	if err != nil {
+		if c, ok := body.(io.Closer); ok {
+			c.Close()
+		}
 		return nil, &BotError{
 			Code:    http.StatusInternalServerError,
 			Message: "Failed to create request",
@@ -56,7 +156,10 @@ func makeAPIRequestWithResult(token, method string, params map[string]interface{
 		}
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	for key, values := range call.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", "application/json")
 
 	resp, err := client.Do(req)
@@ -91,6 +194,7 @@ func makeAPIRequestWithResult(token, method string, params map[string]interface{
 		apiError := &APIError{
 			Code:        telegramResp.ErrorCode,
 			Description: telegramResp.Description,
+			Parameters:  telegramResp.Parameters,
 		}
 
 		switch apiError.Code {
@@ -130,166 +234,146 @@ func makeAPIRequestWithResult(token, method string, params map[string]interface{
 	return telegramResp.Result, nil
 }
 
-func makeMultipartReq(token, method string, params map[string]interface{}, paramName, path string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", token, method)
-
-	fmt.Println("FilePath: ", path)
-	fmt.Println("FileName: ", filepath.Base(path))
-	file, err := os.Open(path)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile(paramName, filepath.Base(path))
-	if err != nil {
-		return fmt.Errorf("failed to create form file: %w", err)
-	}
-
-	_, err = io.Copy(part, file)
-	if err != nil {
-		return fmt.Errorf("failed to write file to form: %w", err)
-	}
-
-	for key, val := range params {
-		strVal := fmt.Sprintf("%v", val)
-		err = writer.WriteField(key, strVal)
+// encodeParams encodes params as JSON, or as multipart form data when they
+// contain files to upload.
+func encodeParams(params map[string]interface{}) (io.Reader, string, error) {
+	if !hasUploads(params) {
+		body, err := json.Marshal(params)
 		if err != nil {
-			return fmt.Errorf("failed to write form field %q: %w", key, err)
+			return nil, "", err
 		}
+		return bytes.NewReader(body), "application/json", nil
 	}
-
-	err = writer.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close writer: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPost, url, body)
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	fmt.Println(string(respBody))
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected response status: %d, body: %s", resp.StatusCode, string(respBody))
-	}
-
-	return nil
+	return encodeMultipart(params)
 }
 
-func makeMultipartMediaGroupReq(token, method string, mediaGroup *SendMediaGroupRequest, files []MediaFile) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", token, method)
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	attachments := make(map[string]string)
-	for i, file := range files {
-		if file.FilePath != "" {
-			f, err := os.Open(file.FilePath)
-			if err != nil {
-				return fmt.Errorf("failed to open file %s: %w", file.FilePath, err)
+func hasUploads(params map[string]interface{}) bool {
+	for _, val := range params {
+		switch v := val.(type) {
+		case *InputFile:
+			if v.isUpload() {
+				return true
 			}
-			defer f.Close()
-
-			attachmentKey := fmt.Sprintf("file%d", i)
-			attachments[attachmentKey] = filepath.Base(file.FilePath)
-
-			mediaGroup.Media[i].Media = "attach://" + attachmentKey
-
-			part, err := writer.CreateFormFile(attachmentKey, filepath.Base(file.FilePath))
-			if err != nil {
-				return fmt.Errorf("failed to create form file: %w", err)
-			}
-
-			if _, err := io.Copy(part, f); err != nil {
-				return fmt.Errorf("failed to copy file content: %w", err)
+		case []InputMedia:
+			for _, media := range v {
+				if media.Media.isUpload() || media.Thumbnail.isUpload() {
+					return true
+				}
 			}
 		}
 	}
+	return false
+}
 
-	mediaBytes, err := json.Marshal(mediaGroup.Media)
-	if err != nil {
-		return fmt.Errorf("failed to marshal media group: %w", err)
-	}
-
-	fields := map[string]string{
-		"chat_id": fmt.Sprintf("%d", mediaGroup.ChatID),
-		"media":   string(mediaBytes),
-	}
-
-	if mediaGroup.DisableNotification {
-		fields["disable_notification"] = "true"
+// encodeMultipart streams params as multipart form data. Files are opened
+// before it returns so missing or consumed files fail the call right away.
+func encodeMultipart(params map[string]interface{}) (io.Reader, string, error) {
+	// top level uploads are sent under their parameter name, uploads nested
+	// in media arrays are attached under generated names
+	fields := make(map[string]string)
+	uploads := make(map[string]*InputFile)
+	attached := make(map[*InputFile]string)
+	attach := func(f *InputFile) *InputFile {
+		if !f.isUpload() {
+			return f
+		}
+		name, ok := attached[f]
+		if !ok {
+			name = fmt.Sprintf("file%d", len(attached))
+			attached[f] = name
+			uploads[name] = f
+		}
+		return FileFromID("attach://" + name)
 	}
 
-	if mediaGroup.ProtectContent {
-		fields["protect_content"] = "true"
-	}
+	for key, val := range params {
+		switch v := val.(type) {
+		case *InputFile:
+			if v.isUpload() {
+				uploads[key] = v
+				continue
+			}
+		case []InputMedia:
+			// the caller's media keep their files, the copies reference
+			// the attached uploads
+			media := make([]InputMedia, len(v))
+			for i, m := range v {
+				m.Media = attach(m.Media)
+				m.Thumbnail = attach(m.Thumbnail)
+				media[i] = m
+			}
+			val = media
+		}
 
-	if mediaGroup.ReplyParams != nil {
-		replyBytes, err := json.Marshal(mediaGroup.ReplyParams)
+		value, err := formValue(val)
 		if err != nil {
-			return fmt.Errorf("failed to marshal reply parameters: %w", err)
+			return nil, "", fmt.Errorf("failed to encode form field %q: %w", key, err)
 		}
-		fields["reply_parameters"] = string(replyBytes)
+		fields[key] = value
 	}
 
-	for key, value := range fields {
-		if err := writer.WriteField(key, value); err != nil {
-			return fmt.Errorf("failed to write field %s: %w", key, err)
+	files := make([]formFile, 0, len(uploads))
+	closeFiles := func() {
+		for _, f := range files {
+			f.r.Close()
 		}
 	}
-
-	if err := writer.Close(); err != nil {
-		return fmt.Errorf("failed to close writer: %w", err)
+	for _, name := range slices.Sorted(maps.Keys(uploads)) {
+		r, err := uploads[name].open()
+		if err != nil {
+			closeFiles()
+			return nil, "", fmt.Errorf("failed to open file %q: %w", name, err)
+		}
+		files = append(files, formFile{field: name, name: uploads[name].fileName(), r: r})
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, body)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		defer closeFiles()
+		pw.CloseWithError(writeMultipart(writer, fields, files))
+	}()
+	return pr, writer.FormDataContentType(), nil
+}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+type formFile struct {
+	field, name string
+	r           io.ReadCloser
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+func writeMultipart(writer *multipart.Writer, fields map[string]string, files []formFile) error {
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		if err := writer.WriteField(key, fields[key]); err != nil {
+			return fmt.Errorf("failed to write form field %q: %w", key, err)
+		}
 	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+	for _, f := range files {
+		part, err := writer.CreateFormFile(f.field, f.name)
+		if err != nil {
+			return fmt.Errorf("failed to create form file: %w", err)
+		}
+		if _, err := io.Copy(part, f.r); err != nil {
+			return fmt.Errorf("failed to write file to form: %w", err)
+		}
 	}
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
 	}
-
 	return nil
 }
 
-func IsAPIError(err error, errCode int) bool {
-	if botErr, ok := err.(*BotError); ok {
-		if apiErr, ok := botErr.Err.(*APIError); ok {
-			return apiErr.Code == errCode
-		}
+// formValue converts a parameter to its form representation, scalars are
+// written as is and everything else as JSON.
+func formValue(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case *InputFile:
+		return v.String(), nil
+	case int, int32, int64, float64, bool:
+		return fmt.Sprintf("%v", v), nil
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
 	}
-	return false
 }
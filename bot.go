@@ -1,13 +1,16 @@
 package tgx
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/format"
 	"github.com/harshyadavone/tgx/pkg/logger"
 )
 
@@ -18,79 +21,65 @@ type (
 )
 
 type Bot struct {
-	token      string
-	webhookURL string
+	token       string
+	webhookURL  string
+	apiURL      string
+	localMode   bool          // self-hosted Bot API server started with --local
+	secretToken *atomic.Value // string, read by concurrent webhook requests
 
-	messageHandlers  map[string]Handler
-	commandHandler   map[string]Handler
-	callbackHandlers map[string]callbackHandler
-	errorHandler     ErrorHandler
+	// ctx is the context calls are made in, set by WithContext
+	ctx context.Context
 
-	logger logger.Logger
-}
+	maxDownloadSize int64
 
-type WebhookInfo struct {
-	URL                          string   `json:"url"`
-	HasCustomCertificate         bool     `json:"has_custom_certificate"`
-	PendingUpdateCount           int      `json:"pending_update_count"`
-	IPAddress                    string   `json:"ip_address,omitempty"`
-	LastErrorDate                int64    `json:"last_error_date,omitempty"`
-	LastErrorMessage             string   `json:"last_error_message,omitempty"`
-	LastSynchronizationErrorDate int64    `json:"last_synchronization_error_date,omitempty"`
-	MaxConnections               int      `json:"max_connections,omitempty"`
-	AllowedUpdates               []string `json:"allowed_updates,omitempty"`
-}
+	messageHandlers    map[string]Handler
+	commandHandler     map[string]Handler
+	callbackHandlers   map[string]callbackHandler
+	errorHandler       ErrorHandler
+	updateErrorHandler UpdateErrorHandler
 
-func NewBot(token, webhookURL string, logger logger.Logger) *Bot {
-	return &Bot{
-		token:            token,
-		webhookURL:       webhookURL,
-		messageHandlers:  make(map[string]Handler),
-		commandHandler:   make(map[string]Handler),
-		callbackHandlers: make(map[string]callbackHandler),
-		logger:           logger,
-		errorHandler:     defaultErrorHandler,
-	}
-}
+	pool           *workerPool
+	updateStore    UpdateStore
+	webhookReplies bool
 
-func defaultErrorHandler(ctx *Context, err error) {
-	ctx.bot.logger.Error("Bot error:", err)
-	payload := &SendMessageRequest{
-		ChatId: ctx.ChatID,
-		Text:   "Sorry, something went wrong. Please try again later.",
-	}
-	ctx.ReplyWithOpts(payload)
-}
+	maxBodySize         int64
+	webhookErrorHandler func(err *WebhookError)
 
-func (b *Bot) SetWebhook() error {
-	return makeAPIRequest(b.token, "setWebhook", map[string]interface{}{
-		"url": b.webhookURL,
-	})
-}
+	recorder *Recorder
+	metrics  Metrics
+	tracer   Tracer
+
+	apiMiddleware []APIMiddleware
+
+	chatMigratedHook   func(oldChatID, newChatID int64)
+	panicHook          func(update *models.Update, err *PanicError)
+	retryMigratedChats bool
 
-func (b *Bot) DeleteWebhook() error {
-	return makeAPIRequest(b.token, "deleteWebhook", map[string]interface{}{})
+	logger logger.Logger
 }
 
-func (b *Bot) GetWebhookInfo() (*WebhookInfo, error) {
-	result, err := makeAPIRequestWithResult(b.token, "getWebhookInfo", nil)
-	if err != nil {
-		return nil, err
+// NewBot creates a bot, a nil logger discards the library's logs.
+func NewBot(token, webhookURL string, log logger.Logger) *Bot {
+	if log == nil {
+		log = logger.NopLogger{}
 	}
-
-	var webhookInfo WebhookInfo
-	if err := json.Unmarshal(result, &webhookInfo); err != nil {
-		return nil, &BotError{
-			Code:    http.StatusBadRequest,
-			Message: "failed to decode webhook info",
-			Err:     err,
-		}
+	return &Bot{
+		token:              token,
+		webhookURL:         webhookURL,
+		apiURL:             defaultAPIURL,
+		secretToken:        new(atomic.Value),
+		messageHandlers:    make(map[string]Handler),
+		commandHandler:     make(map[string]Handler),
+		callbackHandlers:   make(map[string]callbackHandler),
+		logger:             log,
+		metrics:            nopMetrics{},
+		tracer:             nopTracer{},
+		updateErrorHandler: DefaultErrorHandler,
 	}
-	return &webhookInfo, nil
 }
 
 func (b *Bot) GetMe() (*models.User, error) {
-	result, err := makeAPIRequestWithResult(b.token, "getMe", nil)
+	result, err := b.makeAPIRequestWithResult("getMe", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -107,57 +96,67 @@ func (b *Bot) GetMe() (*models.User, error) {
 }
 
 func (b *Bot) logOut() error {
-	return makeAPIRequest(b.token, "getMe", nil)
+	return b.makeAPIRequest("getMe", nil)
 }
 
 func (b *Bot) close() error {
-	return makeAPIRequest(b.token, "close", nil)
+	return b.makeAPIRequest("close", nil)
 }
 
-func (b *Bot) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		b.logger.Error("Invalid HTTP method: %s", r.Method)
-		http.Error(w, "Only POST requests are allowed", http.StatusMethodNotAllowed)
-		return
-	}
+// ProcessUpdate dispatches an update to the registered handlers. It is
+// called by HandleWebhook and can be used to feed updates from other sources.
+func (b *Bot) ProcessUpdate(update *models.Update) {
+	b.processUpdate(context.Background(), update, nil)
+}
 
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		b.logger.Error("Code : %d,\nMessage: %s,\nErr: %v", http.StatusBadRequest, "Failed to read request body", err)
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
+func (b *Bot) processUpdate(ctx context.Context, update *models.Update, reply *webhookReply) {
+	ctx = withWebhookReply(ctx, reply)
+	b.recordUpdate(update)
+	b.metrics.UpdateReceived(updateType(update))
 
-	var update models.Update
-	if err := json.Unmarshal(body, &update); err != nil {
-		b.logger.Error("Code : %d,\nMessage: %s,\nErr: %v", http.StatusBadRequest, "Failed to decode body", err)
-		http.Error(w, "Failed to decode update", http.StatusBadRequest)
-		return
+	attrs := []Attribute{
+		Attr(AttrUpdateID, update.UpdateId),
+		Attr(AttrUpdateType, updateType(update)),
 	}
+	if chatID, ok := updateChatID(update); ok {
+		attrs = append(attrs, Attr(AttrChatID, chatID))
+	}
+	if userID, ok := updateUserID(update); ok {
+		attrs = append(attrs, Attr(AttrUserID, userID))
+	}
+	ctx, span := b.tracer.Start(ctx, SpanUpdate, attrs...)
+	defer span.End()
 
+	start := time.Now()
+	defer func() {
+		b.logger.Debug("Processed update", "update_id", update.UpdateId, "duration", time.Since(start))
+	}()
 	defer func() {
 		if r := recover(); r != nil {
-			b.logger.Error("Panic recovered in update handler: %v", r)
+			err := b.recovered(update, r)
+			span.RecordError(err)
 		}
 	}()
 
+	// migration service messages have nothing for handlers
+	if update.Message != nil && b.handleMigration(update.Message) {
+		return
+	}
+
 	if update.Message != nil {
-		if err := b.handleMessageUpdate(update.Message); err != nil {
-			b.logger.Error("Error handling message update: %v", err)
+		if err := b.handleMessageUpdate(ctx, update, reply); err != nil {
+			span.RecordError(err)
+			b.logger.Error("Error handling message update", "update_id", update.UpdateId, "chat_id", update.Message.Chat.Id, "error", err)
 		}
 	} else if update.CallbackQuery != nil {
-		if err := b.handleCallbackQuery(update.CallbackQuery); err != nil {
-			b.logger.Error("Error handling callback query: %v", err)
-		}
+		b.handleCallbackQuery(ctx, update, reply)
 	} else {
-		b.logger.Warn("Received update with no message or callback query")
+		b.logger.Warn("Received update with no message or callback query", "update_id", update.UpdateId)
 	}
-
-	w.WriteHeader(http.StatusOK)
 }
 
-func (b *Bot) handleMessageUpdate(message *models.Message) error {
+func (b *Bot) handleMessageUpdate(reqCtx context.Context, update *models.Update, reply *webhookReply) error {
+	message := update.Message
 	if message == nil {
 		return &BotError{
 			Code:    http.StatusBadRequest,
@@ -165,26 +164,26 @@ func (b *Bot) handleMessageUpdate(message *models.Message) error {
 		}
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			b.logger.Error("Panic recovered in handleMessageUpdate: %v", r)
-		}
-	}()
-
 	ctx := &Context{
-		Text:      message.Text,
-		UserID:    message.From.Id,
-		Username:  message.From.Username,
-		MessageId: message.MessageId,
-		ChatID:    message.Chat.Id,
-		bot:       b,
+		Text:            message.Text,
+		Entities:        message.Entities,
+		Caption:         message.Caption,
+		CaptionEntities: message.CaptionEntities,
+		UserID:          message.From.Id,
+		Username:        message.From.Username,
+		MessageId:       message.MessageId,
+		ChatID:          message.Chat.Id,
+		bot:             b,
+		webhookReply:    reply,
+		reqCtx:          reqCtx,
+		update:          update,
 	}
 
 	if strings.HasPrefix(message.Text, "/") {
 
 		parts := strings.Split(message.Text, " ")
 		if len(parts) < 1 {
-			b.logger.Error("Not a valid command")
+			b.logger.Error("Not a valid command", "chat_id", message.Chat.Id)
 			return &BotError{
 				Code:    http.StatusInternalServerError,
 				Message: "Failed to parse command",
@@ -194,7 +193,7 @@ func (b *Bot) handleMessageUpdate(message *models.Message) error {
 
 		command := strings.Split(parts[0], "/")
 		if len(command) <= 1 {
-			b.logger.Error("Not a valid command")
+			b.logger.Error("Not a valid command", "chat_id", message.Chat.Id)
 			return &BotError{
 				Code:    http.StatusInternalServerError,
 				Message: "Not a valid command",
@@ -207,16 +206,12 @@ func (b *Bot) handleMessageUpdate(message *models.Message) error {
 			ctx.Args = args
 		}
 
-		b.logger.Debug("Received message: %s", message.Text)
-		b.logger.Debug("Parsed command: %s", command[1])
-		if len(ctx.Args) > 0 {
-			b.logger.Debug("Arguments: [%s]", strings.Join(ctx.Args, ", "))
-		}
+		b.logger.Debug("Parsed command", "chat_id", message.Chat.Id, "command", command[1], "args", ctx.Args)
 
 		if handler, ok := b.commandHandler[command[1]]; ok {
-			b.logger.Info("Executing command: %s", command[1])
+			b.logger.Info("Executing command", "chat_id", message.Chat.Id, "command", command[1])
 
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "command:"+command[1], handler)
 		} else {
 			return &BotError{
 				Code:    http.StatusNotFound,
@@ -229,47 +224,47 @@ func (b *Bot) handleMessageUpdate(message *models.Message) error {
 	switch {
 	case message.Text != "":
 		if handler, ok := b.messageHandlers["Text"]; ok {
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "message:Text", handler)
 		}
 	case message.Photo != nil:
 		if handler, ok := b.messageHandlers["Photo"]; ok {
 			ctx.Photo = message.Photo
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "message:Photo", handler)
 		}
 	case message.Video != nil:
 		if handler, ok := b.messageHandlers["Video"]; ok {
 			ctx.Video = message.Video
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "message:Video", handler)
 		}
 	case message.Voice != nil:
 		if handler, ok := b.messageHandlers["Voice"]; ok {
 			ctx.Voice = message.Voice
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "message:Voice", handler)
 		}
 	case message.Document != nil:
 		if handler, ok := b.messageHandlers["Document"]; ok {
 			ctx.Document = message.Document
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "message:Document", handler)
 		}
 	case message.Animation != nil:
 		if handler, ok := b.messageHandlers["Animation"]; ok {
 			ctx.Animation = message.Animation
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "message:Animation", handler)
 		}
 	case message.Sticker != nil:
 		if handler, ok := b.messageHandlers["Sticker"]; ok {
 			ctx.Sticker = message.Sticker
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "message:Sticker", handler)
 		}
 	case message.Audio != nil:
 		if handler, ok := b.messageHandlers["Audio"]; ok {
 			ctx.Audio = message.Audio
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "message:Audio", handler)
 		}
 	case message.VideoNote != nil:
 		if handler, ok := b.messageHandlers["VideoNote"]; ok {
 			ctx.VideoNote = message.VideoNote
-			return b.safeExecute(ctx, handler)
+			return b.safeExecute(ctx, "message:VideoNote", handler)
 		}
 	default:
 		return &BotError{
@@ -282,59 +277,82 @@ func (b *Bot) handleMessageUpdate(message *models.Message) error {
 	return nil
 }
 
-func (b *Bot) safeExecute(ctx *Context, handler Handler) error {
+func (b *Bot) safeExecute(ctx *Context, name string, handler Handler) (err error) {
+	parent := ctx.reqCtx
+	var span Span
+	ctx.reqCtx, span = b.tracer.Start(ctx.Context(), SpanHandler, Attr(AttrHandler, name))
+	start := time.Now()
 	defer func() {
-		if r := recover(); r != nil {
-			b.logger.Error("Panic in handler execution:", r)
-			err := fmt.Errorf("handler panic: %v", r)
-			if b.errorHandler != nil {
-				b.errorHandler(ctx, err)
-			}
+		b.metrics.HandlerDone(name, time.Since(start), err)
+		if err != nil {
+			span.RecordError(err)
 		}
+		span.End()
+		ctx.reqCtx = parent
 	}()
-	err := handler(ctx)
-	if err != nil {
-		switch {
-		case IsAPIError(err, 403):
-			b.logger.Warn("Bot blocked by user: %d", ctx.UserID)
-			return err
-		case IsAPIError(err, 429):
-			b.logger.Info("Rate limited")
-			return err
-		default:
-			if b.errorHandler != nil {
-				b.errorHandler(ctx, err)
-			}
-			return err
+	defer func() {
+		if r := recover(); r != nil {
+			err = b.recovered(ctx.update, r)
 		}
-	}
-	return nil
+		if err != nil {
+			b.handleError(b.errorContext(ctx.reqCtx, ctx.update, ctx.webhookReply, name), ctx, err)
+		}
+	}()
+	return handler(ctx)
 }
 
 // SendMessage
 
 func (b *Bot) SendMessage(chatID int64, text string) error {
-	return makeAPIRequest(b.token, "sendMessage", map[string]interface{}{
+	return b.makeAPIRequest("sendMessage", map[string]interface{}{
 		"chat_id": chatID,
 		"text":    text,
 	})
 }
 
 func (b *Bot) SendMessageWithOpts(req *SendMessageRequest) error {
-	payload := map[string]interface{}{
-		"chat_id": req.ChatId,
-		"text":    req.Text,
+	payload, err := sendMessagePayload(req)
+	if err != nil {
+		return err
 	}
+	return b.makeAPIRequest("sendMessage", payload)
+}
 
-	if req.ParseMode != "" {
-		if req.ParseMode != HTML && req.ParseMode != MarkdownV2 {
+// setTextFormat adds the parse mode or the entities of a message text to
+// payload, they can't be used together.
+func setTextFormat(payload map[string]interface{}, mode ParseMode, entities []models.MessageEntity) error {
+	if mode != "" {
+		if mode != HTML && mode != MarkdownV2 {
 			return &BotError{
 				Code:    http.StatusBadRequest,
 				Message: "Invalid ParseMode. It must be 'MarkdownV2' or 'HTML'",
-				Err:     fmt.Errorf("invalid ParseMode provided: %s", req.ParseMode),
+				Err:     fmt.Errorf("invalid ParseMode provided: %s", mode),
 			}
 		}
-		payload["parse_mode"] = req.ParseMode
+		payload["parse_mode"] = mode
+	}
+
+	if len(entities) > 0 {
+		if mode != "" {
+			return &BotError{
+				Code:    http.StatusBadRequest,
+				Message: "ParseMode and Entities can't be used together",
+				Err:     fmt.Errorf("both ParseMode (%s) and Entities provided", mode),
+			}
+		}
+		payload["entities"] = entities
+	}
+	return nil
+}
+
+func sendMessagePayload(req *SendMessageRequest) (map[string]interface{}, error) {
+	payload := map[string]interface{}{
+		"chat_id": req.ChatId,
+		"text":    req.Text,
+	}
+
+	if err := setTextFormat(payload, req.ParseMode, req.Entities); err != nil {
+		return nil, err
 	}
 
 	if req.ReplyMarkup != nil {
@@ -351,13 +369,13 @@ func (b *Bot) SendMessageWithOpts(req *SendMessageRequest) error {
 		payload["reply_parameters"] = replyParam
 	}
 
-	return makeAPIRequest(b.token, "sendMessage", payload)
+	return payload, nil
 }
 
 // Forward Message
 
 func (b *Bot) ForwardMessage(chatId, fromChatId, messageId int64) error {
-	return makeAPIRequest(b.token, "forwardMessage", map[string]interface{}{
+	return b.makeAPIRequest("forwardMessage", map[string]interface{}{
 		"chat_id":      chatId,
 		"from_chat_id": fromChatId,
 		"message_id":   messageId,
@@ -379,13 +397,13 @@ func (b *Bot) ForwardMessageWithOpts(req *ForwardMessageRequest) error {
 		payload["protect_content"] = req.ProtectContent
 	}
 
-	return makeAPIRequest(b.token, "forwardMessage", payload)
+	return b.makeAPIRequest("forwardMessage", payload)
 }
 
 // ForwardMessages
 
 func (b *Bot) ForwardMessages(chatId, fromChatId int64, messageId []int64) error {
-	return makeAPIRequest(b.token, "forwardMessages", map[string]interface{}{
+	return b.makeAPIRequest("forwardMessages", map[string]interface{}{
 		"chat_id":      chatId,
 		"from_chat_id": fromChatId,
 		"message_id":   messageId,
@@ -407,13 +425,13 @@ func (b *Bot) ForwardMessagesWithOpts(req *ForwardMessagesRequest) error {
 		payload["protect_content"] = req.ProtectContent
 	}
 
-	return makeAPIRequest(b.token, "forwardMessages", payload)
+	return b.makeAPIRequest("forwardMessages", payload)
 }
 
 // CopyMessage
 
 func (b *Bot) CopyMessage(chatId, fromChatId, messageId int64) error {
-	return makeAPIRequest(b.token, "copyMessage", map[string]interface{}{
+	return b.makeAPIRequest("copyMessage", map[string]interface{}{
 		"chat_id":      chatId,
 		"from_chat_id": fromChatId,
 		"message_id":   messageId,
@@ -472,13 +490,13 @@ func (b *Bot) CopyMessageWithOpts(req *CopyMessageRequest) error {
 		payload["reply_parameters"] = replyParam
 	}
 
-	return makeAPIRequest(b.token, "copyMessage", payload)
+	return b.makeAPIRequest("copyMessage", payload)
 }
 
 // CopyMessages
 
 func (b *Bot) CopyMessages(chatId, fromChatId int64, messageId []int64) error {
-	return makeAPIRequest(b.token, "copyMessages", map[string]interface{}{
+	return b.makeAPIRequest("copyMessages", map[string]interface{}{
 		"chat_id":      chatId,
 		"from_chat_id": fromChatId,
 		"message_id":   messageId,
@@ -504,18 +522,33 @@ func (b *Bot) CopyMessagesWithOpts(req *CopyMessagesRequest) error {
 		payload["remove_caption"] = req.RemoveCaption
 	}
 
-	return makeAPIRequest(b.token, "copyMessages", payload)
+	return b.makeAPIRequest("copyMessages", payload)
 }
 
-// file_id or url
-func (b *Bot) SendPhoto(req *SendPhotoRequest) error {
-	builder := NewParamBuilder().
+// sendMedia adds the options shared by all media requests and sends them
+func (b *Bot) sendMedia(method string, builder *ParamBuilder, req *BaseMediaRequest) error {
+	builder.
 		Add("chat_id", req.ChatId).
-		Add("caption", req.Caption).
-		Add("photo", req.Photo).
 		Add("disable_notification", req.DisableNotification).
-		Add("show_caption_above_media", req.ShowCaptionAboveMedia).
-		Add("has_spoiler", req.HasSpoiler)
+		Add("protect_content", req.ProtectContent)
+
+	var overflow []format.Chunk
+	if req.SplitCaption && format.UTF16Len(req.Caption) > format.MaxCaptionLength {
+		caption, rest, err := splitCaption(req.Caption, req.ParseMode)
+		if err != nil {
+			return err
+		}
+		builder.Add("caption", caption.Text)
+		if len(caption.Entities) > 0 {
+			entities, _ := json.Marshal(caption.Entities)
+			builder.Add("caption_entities", string(entities))
+		}
+		overflow = rest
+	} else {
+		builder.
+			Add("caption", req.Caption).
+			Add("parse_mode", string(req.ParseMode))
+	}
 
 	if req.ReplyParams != nil {
 		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
@@ -524,421 +557,262 @@ func (b *Bot) SendPhoto(req *SendPhotoRequest) error {
 		replyBytes, _ := json.Marshal(req.ReplyMarkup)
 		builder.Add("reply_markup", string(replyBytes))
 	}
+	if err := b.makeAPIRequest(method, builder.Build()); err != nil {
+		return err
+	}
 
-	params := builder.Build()
-	return makeAPIRequest(b.token, "sendPhoto", params)
+	for _, chunk := range overflow {
+		payload := map[string]interface{}{
+			"chat_id": req.ChatId,
+			"text":    chunk.Text,
+		}
+		if len(chunk.Entities) > 0 {
+			payload["entities"] = chunk.Entities
+		}
+		if req.DisableNotification {
+			payload["disable_notification"] = true
+		}
+		if _, err := b.sendMessageWithResult(payload); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// file_path
-func (b *Bot) SendPhotoFile(req *SendPhotoRequest) error {
-	b.logger.Debug("Preparing to send photo")
-
-	if req.Photo == "" {
-		b.logger.Error("Photo is nil in SendPhotoFile request")
+// SendPhoto sends a photo by file_id, URL or upload
+func (b *Bot) SendPhoto(req *SendPhotoRequest) error {
+	if req.Photo == nil {
 		return &BotError{
+			Code:    http.StatusBadRequest,
 			Message: "photo can't be nil",
 		}
 	}
 
 	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("caption", req.Caption)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-
-	params := builder.Build()
-	b.logger.Debug("Sending photo request")
-	return makeMultipartReq(b.token, "sendPhoto", params, "photo", req.Photo)
+		Add("photo", req.Photo).
+		Add("show_caption_above_media", req.ShowCaptionAboveMedia).
+		Add("has_spoiler", req.HasSpoiler)
+	return b.sendMedia("sendPhoto", builder, &req.BaseMediaRequest)
 }
 
-// Send Audio with file_id or URL
-func (b *Bot) SendAudio(req *SendAudioRequest) error {
-	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("audio", req.Audio).
-		Add("caption", req.Caption).
-		Add("duration", req.Duration).
-		Add("performer", req.Performer).
-		Add("title", req.Title).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	return makeAPIRequest(b.token, "sendAudio", params)
+// SendPhotoFile uploads a file from disk.
+//
+// Deprecated: SendPhoto accepts any InputFile, use it with FileFromPath.
+func (b *Bot) SendPhotoFile(req *SendPhotoRequest) error {
+	return b.SendPhoto(req)
 }
 
-// Send Audio with file path
-func (b *Bot) SendAudioFile(req *SendAudioRequest) error {
-	b.logger.Debug("Preparing to send audio")
-	if req.Audio == "" {
-		b.logger.Error("Audio is nil in SendAudioFile request")
+// SendAudio sends an audio file to be shown in the music player
+func (b *Bot) SendAudio(req *SendAudioRequest) error {
+	if req.Audio == nil {
 		return &BotError{
+			Code:    http.StatusBadRequest,
 			Message: "audio can't be nil",
 		}
 	}
 
 	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("caption", req.Caption).
+		Add("audio", req.Audio).
+		Add("thumbnail", req.Thumbnail).
 		Add("duration", req.Duration).
 		Add("performer", req.Performer).
-		Add("title", req.Title).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	b.logger.Debug("Sending audio request")
-	return makeMultipartReq(b.token, "sendAudio", params, "audio", req.Audio)
+		Add("title", req.Title)
+	return b.sendMedia("sendAudio", builder, &req.BaseMediaRequest)
 }
 
-// Send Video with file_id or URL
-func (b *Bot) SendVideo(req *SendVideoRequest) error {
-	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("video", req.Video).
-		Add("caption", req.Caption).
-		Add("duration", req.Duration).
-		Add("width", req.Width).
-		Add("height", req.Height).
-		Add("supports_streaming", req.SupportsStreaming).
-		Add("has_spoiler", req.HasSpoiler).
-		Add("show_caption_above_media", req.ShowCaptionAboveMedia).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	return makeAPIRequest(b.token, "sendVideo", params)
+// SendAudioFile uploads a file from disk.
+//
+// Deprecated: SendAudio accepts any InputFile, use it with FileFromPath.
+func (b *Bot) SendAudioFile(req *SendAudioRequest) error {
+	return b.SendAudio(req)
 }
 
-// Send Video with file path
-func (b *Bot) SendVideoFile(req *SendVideoRequest) error {
-	b.logger.Debug("Preparing to send video")
-	if req.Video == "" {
-		b.logger.Error("Video is nil in SendVideoFile request")
+// SendVideo sends a video
+func (b *Bot) SendVideo(req *SendVideoRequest) error {
+	if req.Video == nil {
 		return &BotError{
+			Code:    http.StatusBadRequest,
 			Message: "video can't be nil",
 		}
 	}
 
 	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("caption", req.Caption).
+		Add("video", req.Video).
+		Add("thumbnail", req.Thumbnail).
 		Add("duration", req.Duration).
 		Add("width", req.Width).
 		Add("height", req.Height).
 		Add("supports_streaming", req.SupportsStreaming).
 		Add("has_spoiler", req.HasSpoiler).
-		Add("show_caption_above_media", req.ShowCaptionAboveMedia).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	b.logger.Debug("Sending video request")
-	return makeMultipartReq(b.token, "sendVideo", params, "video", req.Video)
+		Add("show_caption_above_media", req.ShowCaptionAboveMedia)
+	return b.sendMedia("sendVideo", builder, &req.BaseMediaRequest)
 }
 
-// Send Document with file_id or URL
-func (b *Bot) SendDocument(req *SendDocumentRequest) error {
-	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("document", req.Document).
-		Add("caption", req.Caption).
-		Add("disable_content_type_detection", req.DisableContentTypeDetection).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	return makeAPIRequest(b.token, "sendDocument", params)
+// SendVideoFile uploads a file from disk.
+//
+// Deprecated: SendVideo accepts any InputFile, use it with FileFromPath.
+func (b *Bot) SendVideoFile(req *SendVideoRequest) error {
+	return b.SendVideo(req)
 }
 
-// Send Document with file path
-func (b *Bot) SendDocumentFile(req *SendDocumentRequest) error {
-	b.logger.Debug("Preparing to send document")
-	if req.Document == "" {
-		b.logger.Error("Document is nil in SendDocumentFile request")
+// SendDocument sends a general file
+func (b *Bot) SendDocument(req *SendDocumentRequest) error {
+	if req.Document == nil {
 		return &BotError{
+			Code:    http.StatusBadRequest,
 			Message: "document can't be nil",
 		}
 	}
 
 	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("caption", req.Caption).
-		Add("disable_content_type_detection", req.DisableContentTypeDetection).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	b.logger.Debug("Sending document request")
-	return makeMultipartReq(b.token, "sendDocument", params, "document", req.Document)
+		Add("document", req.Document).
+		Add("thumbnail", req.Thumbnail).
+		Add("disable_content_type_detection", req.DisableContentTypeDetection)
+	return b.sendMedia("sendDocument", builder, &req.BaseMediaRequest)
 }
 
-// Send Animation with file_id or URL
-func (b *Bot) SendAnimation(req *SendAnimationRequest) error {
-	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("animation", req.Animation).
-		Add("caption", req.Caption).
-		Add("duration", req.Duration).
-		Add("width", req.Width).
-		Add("height", req.Height).
-		Add("has_spoiler", req.HasSpoiler).
-		Add("show_caption_above_media", req.ShowCaptionAboveMedia).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	return makeAPIRequest(b.token, "sendAnimation", params)
+// SendDocumentFile uploads a file from disk.
+//
+// Deprecated: SendDocument accepts any InputFile, use it with FileFromPath.
+func (b *Bot) SendDocumentFile(req *SendDocumentRequest) error {
+	return b.SendDocument(req)
 }
 
-// Send Animation with file path
-func (b *Bot) SendAnimationFile(req *SendAnimationRequest) error {
-	b.logger.Debug("Preparing to send animation")
-	if req.Animation == "" {
-		b.logger.Error("Animation is nil in SendAnimationFile request")
+// SendAnimation sends a GIF or H.264/MPEG-4 AVC video without sound
+func (b *Bot) SendAnimation(req *SendAnimationRequest) error {
+	if req.Animation == nil {
 		return &BotError{
+			Code:    http.StatusBadRequest,
 			Message: "animation can't be nil",
 		}
 	}
 
 	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("caption", req.Caption).
+		Add("animation", req.Animation).
+		Add("thumbnail", req.Thumbnail).
 		Add("duration", req.Duration).
 		Add("width", req.Width).
 		Add("height", req.Height).
 		Add("has_spoiler", req.HasSpoiler).
-		Add("show_caption_above_media", req.ShowCaptionAboveMedia).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	b.logger.Debug("Sending animation request")
-	return makeMultipartReq(b.token, "sendAnimation", params, "animation", req.Animation)
+		Add("show_caption_above_media", req.ShowCaptionAboveMedia)
+	return b.sendMedia("sendAnimation", builder, &req.BaseMediaRequest)
 }
 
-// Send Voice with file_id or URL
-func (b *Bot) SendVoice(req *SendVoiceRequest) error {
-	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("voice", req.Voice).
-		Add("caption", req.Caption).
-		Add("duration", req.Duration).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	return makeAPIRequest(b.token, "sendVoice", params)
+// SendAnimationFile uploads a file from disk.
+//
+// Deprecated: SendAnimation accepts any InputFile, use it with FileFromPath.
+func (b *Bot) SendAnimationFile(req *SendAnimationRequest) error {
+	return b.SendAnimation(req)
 }
 
-// Send Voice with file path
-func (b *Bot) SendVoiceFile(req *SendVoiceRequest) error {
-	b.logger.Debug("Preparing to send voice")
-	if req.Voice == "" {
-		b.logger.Error("Voice is nil in SendVoiceFile request")
+// SendVoice sends an OGG/OPUS voice message
+func (b *Bot) SendVoice(req *SendVoiceRequest) error {
+	if req.Voice == nil {
 		return &BotError{
+			Code:    http.StatusBadRequest,
 			Message: "voice can't be nil",
 		}
 	}
 
 	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("caption", req.Caption).
-		Add("duration", req.Duration).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	b.logger.Debug("Sending voice request")
-	return makeMultipartReq(b.token, "sendVoice", params, "voice", req.Voice)
+		Add("voice", req.Voice).
+		Add("duration", req.Duration)
+	return b.sendMedia("sendVoice", builder, &req.BaseMediaRequest)
 }
 
-// Send VideoNote with file_id or URL
-func (b *Bot) SendVideoNote(req *SendVideoNoteRequest) error {
-	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("video_note", req.VideoNote).
-		Add("duration", req.Duration).
-		Add("length", req.Length).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	return makeAPIRequest(b.token, "sendVideoNote", params)
+// SendVoiceFile uploads a file from disk.
+//
+// Deprecated: SendVoice accepts any InputFile, use it with FileFromPath.
+func (b *Bot) SendVoiceFile(req *SendVoiceRequest) error {
+	return b.SendVoice(req)
 }
 
-// Send VideoNote with file path
-func (b *Bot) SendVideoNoteFile(req *SendVideoNoteRequest) error {
-	b.logger.Debug("Preparing to send video note")
-	if req.VideoNote == "" {
-		b.logger.Error("VideoNote is nil in SendVideoNoteFile request")
+// SendVideoNote sends a rounded square video message
+func (b *Bot) SendVideoNote(req *SendVideoNoteRequest) error {
+	if req.VideoNote == nil {
 		return &BotError{
+			Code:    http.StatusBadRequest,
 			Message: "video note can't be nil",
 		}
 	}
 
 	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
+		Add("video_note", req.VideoNote).
+		Add("thumbnail", req.Thumbnail).
 		Add("duration", req.Duration).
-		Add("length", req.Length).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	b.logger.Debug("Sending video note request")
-	return makeMultipartReq(b.token, "sendVideoNote", params, "video_note", req.VideoNote)
+		Add("length", req.Length)
+	return b.sendMedia("sendVideoNote", builder, &req.BaseMediaRequest)
 }
 
-// SendSticker sends a sticker using file ID or URL
-func (b *Bot) SendSticker(req *SendStickerRequest) error {
-	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("sticker", req.Sticker).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
-
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	return makeAPIRequest(b.token, "sendSticker", params)
+// SendVideoNoteFile uploads a file from disk.
+//
+// Deprecated: SendVideoNote accepts any InputFile, use it with FileFromPath.
+func (b *Bot) SendVideoNoteFile(req *SendVideoNoteRequest) error {
+	return b.SendVideoNote(req)
 }
 
-// SendStickerFile sends a sticker using a local file path
-func (b *Bot) SendStickerFile(req *SendStickerRequest) error {
-	b.logger.Debug("Preparing to send sticker")
-	if req.Sticker == "" {
-		b.logger.Error("Sticker is nil in SendStickerFile request")
+// SendSticker sends a static, animated or video sticker
+func (b *Bot) SendSticker(req *SendStickerRequest) error {
+	if req.Sticker == nil {
 		return &BotError{
+			Code:    http.StatusBadRequest,
 			Message: "sticker can't be nil",
 		}
 	}
 
 	builder := NewParamBuilder().
-		Add("chat_id", req.ChatId).
-		Add("disable_notification", req.DisableNotification).
-		Add("protect_content", req.ProtectContent)
+		Add("sticker", req.Sticker).
+		Add("emoji", req.Emoji)
+	return b.sendMedia("sendSticker", builder, &req.BaseMediaRequest)
+}
 
-	if req.ReplyParams != nil {
-		builder.Add("reply_to_message_id", req.ReplyParams.MessageId)
-	}
-	if req.ReplyMarkup != nil {
-		replyBytes, _ := json.Marshal(req.ReplyMarkup)
-		builder.Add("reply_markup", string(replyBytes))
-	}
-	params := builder.Build()
-	b.logger.Debug("Sending sticker request")
-	return makeMultipartReq(b.token, "sendSticker", params, "sticker", req.Sticker)
+// SendStickerFile uploads a file from disk.
+//
+// Deprecated: SendSticker accepts any InputFile, use it with FileFromPath.
+func (b *Bot) SendStickerFile(req *SendStickerRequest) error {
+	return b.SendSticker(req)
 }
 
-// SendMediaGroup
-func (b *Bot) SendMediaGroup(chatID int64, media []InputMedia, files []MediaFile) error {
-	req := &SendMediaGroupRequest{
+// SendMediaGroup sends 2-10 photos, videos, documents or audios as an album
+func (b *Bot) SendMediaGroup(chatID int64, media []InputMedia) error {
+	return b.SendMediaGroupWithOpts(&SendMediaGroupRequest{
 		ChatID: chatID,
 		Media:  media,
+	})
+}
+
+func (b *Bot) SendMediaGroupWithOpts(req *SendMediaGroupRequest) error {
+	for i, media := range req.Media {
+		if media.Media == nil {
+			return &BotError{
+				Code:    http.StatusBadRequest,
+				Message: "media can't be nil",
+				Err:     fmt.Errorf("media group item %d has no media", i),
+			}
+		}
+	}
+
+	params := map[string]interface{}{
+		"chat_id": req.ChatID,
+		"media":   req.Media,
+	}
+
+	if req.DisableNotification {
+		params["disable_notification"] = true
+	}
+	if req.ProtectContent {
+		params["protect_content"] = true
+	}
+	if req.ReplyParams != nil {
+		params["reply_parameters"] = req.ReplyParams
 	}
 
-	return makeMultipartMediaGroupReq(b.token, "sendMediaGroup", req, files)
+	return b.makeAPIRequest("sendMediaGroup", params)
 }
 
 // sendChatAction
 func (b *Bot) SendChatAction(chatId int64, action string) error {
-	return makeAPIRequest(b.token, "sendChatAction", map[string]interface{}{
+	return b.makeAPIRequest("sendChatAction", map[string]interface{}{
 		"chat_id": chatId,
 		"action":  action,
 	})
@@ -960,7 +834,7 @@ func (b *Bot) BanChatMember(chatId string, userId, untilDate int32, revokeMessag
 		params["revoke_messages"] = *revokeMessages
 	}
 
-	return makeAPIRequest(b.token, "banChatMember", params)
+	return b.makeAPIRequest("banChatMember", params)
 }
 
 // unbanChatMember
@@ -974,7 +848,7 @@ func (b *Bot) UnbanChatMember(chatId string, userId int32, onlyIfBanned *bool) e
 		params["only_if_banned"] = *onlyIfBanned
 	}
 
-	return makeAPIRequest(b.token, "unbanChatMember", params)
+	return b.makeAPIRequest("unbanChatMember", params)
 }
 
 // restrictChatMember
@@ -997,7 +871,7 @@ func (b *Bot) RestrictChatMember(req *RestrictChatMember) error {
 		"until_date":                       req.UntilDate,
 	}
 
-	return makeAPIRequest(b.token, "restrictChatMember", params)
+	return b.makeAPIRequest("restrictChatMember", params)
 }
 
 // PromoteChatMember promotes a user to an administrator in a chat
@@ -1028,7 +902,7 @@ func (b *Bot) PromoteChatMember(req *PromoteChatMember) error {
 		Add("can_pin_messages", req.CanPinMessages).
 		Add("can_manage_topics", req.CanManageTopics)
 
-	err := makeAPIRequest(b.token, "promoteChatMember", builder.Build())
+	err := b.makeAPIRequest("promoteChatMember", builder.Build())
 	if err != nil {
 		return fmt.Errorf("failed to promote chat member: %w", err)
 	}
@@ -1038,7 +912,7 @@ func (b *Bot) PromoteChatMember(req *PromoteChatMember) error {
 
 // setChatAdministratorCustomTitle
 func (b *Bot) SetChatAdministratorCustomTitle(chatId string, userId int32, customTitle string) error {
-	return makeAPIRequest(b.token, "setChatAdministratorCustomTitle", map[string]interface{}{
+	return b.makeAPIRequest("setChatAdministratorCustomTitle", map[string]interface{}{
 		"chat_id":      chatId,
 		"user_id":      userId,
 		"custom_title": customTitle,
@@ -1047,7 +921,7 @@ func (b *Bot) SetChatAdministratorCustomTitle(chatId string, userId int32, custo
 
 // banChatSenderChat
 func (b *Bot) BanChatSenderChat(chatId string, senderChatId int32) error {
-	return makeAPIRequest(b.token, "banChatSenderChat", map[string]interface{}{
+	return b.makeAPIRequest("banChatSenderChat", map[string]interface{}{
 		"chat_id":        chatId,
 		"sender_chat_id": senderChatId,
 	})
@@ -1055,7 +929,7 @@ func (b *Bot) BanChatSenderChat(chatId string, senderChatId int32) error {
 
 // unbanChatSenderChat
 func (b *Bot) UnbanChatSenderChat(chatId string, senderChatId int32) error {
-	return makeAPIRequest(b.token, "unbanChatSenderChat", map[string]interface{}{
+	return b.makeAPIRequest("unbanChatSenderChat", map[string]interface{}{
 		"chat_id":        chatId,
 		"sender_chat_id": senderChatId,
 	})
@@ -1073,13 +947,13 @@ func (b *Bot) SetChatPermissions(chatId string, chatPermissions ChatPermissions,
 		params["use_independent_chat_permissions"] = *useIndependentChatPermissions
 	}
 
-	return makeAPIRequest(b.token, "setChatPermissions", params)
+	return b.makeAPIRequest("setChatPermissions", params)
 }
 
 // exportChatInviteLink
 func (b *Bot) ExportChatInviteLink(chatId string) (string, error) {
 	// Make the API request, assuming it returns json.RawMessage
-	response, err := makeAPIRequestWithResult(b.token, "exportChatInviteLink", map[string]interface{}{
+	response, err := b.makeAPIRequestWithResult("exportChatInviteLink", map[string]interface{}{
 		"chat_id": chatId,
 	})
 	if err != nil {
@@ -1101,7 +975,7 @@ func (b *Bot) ExportChatInviteLink(chatId string) (string, error) {
 
 // createChatInviteLink
 func (b *Bot) CreateChatInviteLink(chatId string) (map[string]interface{}, error) {
-	response, err := makeAPIRequestWithResult(b.token, "createChatInviteLink", map[string]interface{}{
+	response, err := b.makeAPIRequestWithResult("createChatInviteLink", map[string]interface{}{
 		"chat_id": chatId,
 	})
 	if err != nil {
@@ -1141,7 +1015,7 @@ func (b *Bot) EditChatInviteLink(chatId string, inviteLink string, name *string,
 		params["creates_join_request"] = *createsJoinRequest
 	}
 
-	response, err := makeAPIRequestWithResult(b.token, "editChatInviteLink", params)
+	response, err := b.makeAPIRequestWithResult("editChatInviteLink", params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to edit chat invite link: %w", err)
 	}
@@ -1177,7 +1051,7 @@ func (b *Bot) CreateChatSubscriptionInviteLink(chatId string, name *string, subs
 		params["name"] = *name
 	}
 
-	response, err := makeAPIRequestWithResult(b.token, "createChatSubscriptionInviteLink", params)
+	response, err := b.makeAPIRequestWithResult("createChatSubscriptionInviteLink", params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chat subscription invite link: %w", err)
 	}
@@ -1205,7 +1079,7 @@ func (b *Bot) EditChatSubscriptionInviteLink(chatId string, inviteLink string, n
 		params["name"] = *name
 	}
 
-	response, err := makeAPIRequestWithResult(b.token, "editChatSubscriptionInviteLink", params)
+	response, err := b.makeAPIRequestWithResult("editChatSubscriptionInviteLink", params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to edit chat subscription invite link: %w", err)
 	}
@@ -1229,7 +1103,7 @@ func (b *Bot) RevokeChatInviteLink(chatId string, inviteLink string) (map[string
 		"invite_link": inviteLink,
 	}
 
-	response, err := makeAPIRequestWithResult(b.token, "revokeChatInviteLink", params)
+	response, err := b.makeAPIRequestWithResult("revokeChatInviteLink", params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to revoke chat invite link: %w", err)
 	}
@@ -1253,7 +1127,7 @@ func (b *Bot) ApproveChatJoinRequest(chatId string, userId int) (bool, error) {
 		"user_id": userId,
 	}
 
-	response, err := makeAPIRequestWithResult(b.token, "approveChatJoinRequest", params)
+	response, err := b.makeAPIRequestWithResult("approveChatJoinRequest", params)
 	if err != nil {
 		return false, fmt.Errorf("failed to approve chat join request: %w", err)
 	}
@@ -1277,7 +1151,7 @@ func (b *Bot) DeclineChatJoinRequest(chatId string, userId int) (bool, error) {
 		"user_id": userId,
 	}
 
-	response, err := makeAPIRequestWithResult(b.token, "declineChatJoinRequest", params)
+	response, err := b.makeAPIRequestWithResult("declineChatJoinRequest", params)
 	if err != nil {
 		return false, fmt.Errorf("failed to decline chat join request: %w", err)
 	}
@@ -1300,7 +1174,8 @@ func (b *Bot) SetChatPhoto(chatId string, photoPath string) (bool, error) {
 		"chat_id": chatId,
 	}
 
-	err := makeMultipartReq(b.token, "setChatPhoto", params, "photo", photoPath)
+	params["photo"] = FileFromPath(photoPath)
+	err := b.makeAPIRequest("setChatPhoto", params)
 	if err != nil {
 		return false, fmt.Errorf("failed to set chat photo: %w", err)
 	}
@@ -1309,27 +1184,27 @@ func (b *Bot) SetChatPhoto(chatId string, photoPath string) (bool, error) {
 }
 
 func (b *Bot) DeleteChatPhoto(chatId string) error {
-	return makeAPIRequest(b.token, "deleteChatPhoto", map[string]interface{}{
+	return b.makeAPIRequest("deleteChatPhoto", map[string]interface{}{
 		"chat_id": chatId,
 	})
 }
 
 func (b *Bot) SetChatTitle(chatId, title string) error {
-	return makeAPIRequest(b.token, "setChatTitle", map[string]interface{}{
+	return b.makeAPIRequest("setChatTitle", map[string]interface{}{
 		"chat_id": chatId,
 		"title":   title,
 	})
 }
 
 func (b *Bot) SetChatDescription(chatId, description string) error {
-	return makeAPIRequest(b.token, "setChatDescription", map[string]interface{}{
+	return b.makeAPIRequest("setChatDescription", map[string]interface{}{
 		"chat_id":     chatId,
 		"description": description,
 	})
 }
 
 func (b *Bot) PinChatMessage(chatId string, messageId int64, DisableNotification bool) error {
-	return makeAPIRequest(b.token, "pinChatMessage", map[string]interface{}{
+	return b.makeAPIRequest("pinChatMessage", map[string]interface{}{
 		"chat_id":              chatId,
 		"message_id":           messageId,
 		"disable_notification": DisableNotification,
@@ -1337,64 +1212,64 @@ func (b *Bot) PinChatMessage(chatId string, messageId int64, DisableNotification
 }
 
 func (b *Bot) UnpinChatMessage(chatId string, messageId int64) error {
-	return makeAPIRequest(b.token, "unpinChatMessage", map[string]interface{}{
+	return b.makeAPIRequest("unpinChatMessage", map[string]interface{}{
 		"chat_id":    chatId,
 		"message_id": messageId,
 	})
 }
 
 func (b *Bot) UnpinAllChatMessages(chatId string) error {
-	return makeAPIRequest(b.token, "unpinAllChatMessages", map[string]interface{}{
+	return b.makeAPIRequest("unpinAllChatMessages", map[string]interface{}{
 		"chat_id": chatId,
 	})
 }
 
 func (b *Bot) LeaveChat(chatId string) error {
-	return makeAPIRequest(b.token, "leaveChat", map[string]interface{}{
+	return b.makeAPIRequest("leaveChat", map[string]interface{}{
 		"chat_id": chatId,
 	})
 }
 
 func (b *Bot) GetChat(chatId string) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getChat", map[string]interface{}{
+	return b.makeAPIRequestWithResult("getChat", map[string]interface{}{
 		"chat_id": chatId,
 	})
 }
 
 func (b *Bot) GetChatAdministrators(chatId string) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getChatAdministrators", map[string]interface{}{
+	return b.makeAPIRequestWithResult("getChatAdministrators", map[string]interface{}{
 		"chat_id": chatId,
 	})
 }
 
 func (b *Bot) GetChatMemberCount(chatId string) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getChatMemberCount", map[string]interface{}{
+	return b.makeAPIRequestWithResult("getChatMemberCount", map[string]interface{}{
 		"chat_id": chatId,
 	})
 }
 
 func (b *Bot) GetChatMember(chatId string, userId int64) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getChatMember", map[string]interface{}{
+	return b.makeAPIRequestWithResult("getChatMember", map[string]interface{}{
 		"chat_id": chatId,
 		"user_id": userId,
 	})
 }
 
 func (b *Bot) SetStickerSet(chatId, stickerSetName string) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "setStickerSet", map[string]interface{}{
+	return b.makeAPIRequestWithResult("setStickerSet", map[string]interface{}{
 		"chat_id":          chatId,
 		"sticker_set_name": stickerSetName,
 	})
 }
 
 func (b *Bot) DeleteStickerSet(chatId string) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "deleteChatStickerSet", map[string]interface{}{
+	return b.makeAPIRequestWithResult("deleteChatStickerSet", map[string]interface{}{
 		"chat_id": chatId,
 	})
 }
 
 func (b *Bot) GetForumTopicIconStickers() (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getForumTopicIconStickers", map[string]interface{}{})
+	return b.makeAPIRequestWithResult("getForumTopicIconStickers", map[string]interface{}{})
 }
 
 func (b *Bot) answerCallbackQuery(req *AnswerCallbackQueryRequest) error {
@@ -1418,11 +1293,11 @@ func (b *Bot) answerCallbackQuery(req *AnswerCallbackQueryRequest) error {
 		params["cache_time"] = req.CacheTime
 	}
 
-	return makeAPIRequest(b.token, "answerCallbackQuery", params)
+	return b.makeAPIRequest("answerCallbackQuery", params)
 }
 
 func (b *Bot) GetUserChatBoosts(chatId string, userId int64) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getUserChatBoosts", map[string]interface{}{
+	return b.makeAPIRequestWithResult("getUserChatBoosts", map[string]interface{}{
 		"chat_id": chatId,
 		"user_id": userId,
 	})
@@ -1430,59 +1305,59 @@ func (b *Bot) GetUserChatBoosts(chatId string, userId int64) (json.RawMessage, e
 
 func (b *Bot) SetMyCommands(commands []BotCommand) error {
 	botCommands, _ := json.Marshal(commands)
-	return makeAPIRequest(b.token, "setMyCommands", map[string]interface{}{
+	return b.makeAPIRequest("setMyCommands", map[string]interface{}{
 		"commands": string(botCommands),
 	})
 }
 
 func (b *Bot) DeleteMyCommands() error {
-	return makeAPIRequest(b.token, "deleteMyCommands", map[string]interface{}{})
+	return b.makeAPIRequest("deleteMyCommands", map[string]interface{}{})
 }
 
 func (b *Bot) GetMyCommands() (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getMyCommands", map[string]interface{}{})
+	return b.makeAPIRequestWithResult("getMyCommands", map[string]interface{}{})
 }
 
 func (b *Bot) SetMyName(name, langagueCode string) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "setMyName", map[string]interface{}{
+	return b.makeAPIRequestWithResult("setMyName", map[string]interface{}{
 		"name":          name,
 		"language_code": langagueCode,
 	})
 }
 
 func (b *Bot) GetMyName(name, langagueCode string) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getMyName", map[string]interface{}{
+	return b.makeAPIRequestWithResult("getMyName", map[string]interface{}{
 		"language_code": langagueCode,
 	})
 }
 
 func (b *Bot) SetMyDescription(description, langagueCode string) error {
-	return makeAPIRequest(b.token, "setMyDescription", map[string]interface{}{
+	return b.makeAPIRequest("setMyDescription", map[string]interface{}{
 		"description":   description,
 		"language_code": langagueCode,
 	})
 }
 
 func (b *Bot) GetMyDescription(description, langagueCode string) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getMyDescription", map[string]interface{}{
+	return b.makeAPIRequestWithResult("getMyDescription", map[string]interface{}{
 		"language_code": langagueCode,
 	})
 }
 
 func (b *Bot) SetMyShortDescription(shortDescription, langagueCode string) error {
-	return makeAPIRequest(b.token, "setMyShortDescription", map[string]interface{}{
+	return b.makeAPIRequest("setMyShortDescription", map[string]interface{}{
 		"short_description": shortDescription,
 		"language_code":     langagueCode,
 	})
 }
 
 func (b *Bot) GetMyShortDescription(langagueCode string) (json.RawMessage, error) {
-	return makeAPIRequestWithResult(b.token, "getMyShortDescription", map[string]interface{}{
+	return b.makeAPIRequestWithResult("getMyShortDescription", map[string]interface{}{
 		"language_code": langagueCode,
 	})
 }
 
 func (b *Bot) EditMessageText(req *EditMessageTextRequest) error {
 	builer := NewParamBuilder().Add("chat_id", req.ChatId).Add("message_id", req.MessageId)
-	return makeAPIRequest(b.token, "getMyShortDescription", builer.Build())
+	return b.makeAPIRequest("getMyShortDescription", builer.Build())
 }
@@ -0,0 +1,456 @@
+package tgx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recipients iterates over the chats a broadcast is sent to. The order must
+// be the same on every run for a broadcast to be resumed.
+type Recipients interface {
+	// Next returns the next chat, ok is false once there are no more.
+	Next(ctx context.Context) (chatID int64, ok bool, err error)
+}
+
+// RecipientList iterates over a fixed list of chats.
+func RecipientList(chatIDs ...int64) Recipients {
+	return &recipientList{chatIDs: chatIDs}
+}
+
+type recipientList struct {
+	chatIDs []int64
+	next    int
+}
+
+func (r *recipientList) Next(context.Context) (int64, bool, error) {
+	if r.next >= len(r.chatIDs) {
+		return 0, false, nil
+	}
+	r.next++
+	return r.chatIDs[r.next-1], true, nil
+}
+
+// BroadcastMessage builds the call that sends a broadcast to a chat.
+type BroadcastMessage func(chatID int64) (*APICall, error)
+
+// BroadcastText sends req to every chat, its ChatId is ignored.
+func BroadcastText(req *SendMessageRequest) BroadcastMessage {
+	return func(chatID int64) (*APICall, error) {
+		msg := *req
+		msg.ChatId = chatID
+		params, err := sendMessagePayload(&msg)
+		if err != nil {
+			return nil, err
+		}
+		return &APICall{Method: "sendMessage", Params: params}, nil
+	}
+}
+
+// BroadcastCopy copies a message, e.g. an announcement prepared in a private
+// channel, to every chat.
+func BroadcastCopy(fromChatID, messageID int64) BroadcastMessage {
+	return func(chatID int64) (*APICall, error) {
+		return &APICall{Method: "copyMessage", Params: map[string]interface{}{
+			"chat_id":      chatID,
+			"from_chat_id": fromChatID,
+			"message_id":   messageID,
+		}}, nil
+	}
+}
+
+type BroadcastOptions struct {
+	// ID identifies the broadcast in the Store, a broadcast started again
+	// with the same ID resumes where it stopped
+	ID      string
+	Message BroadcastMessage
+	Store   BroadcastStore // nil doesn't persist progress
+
+	Total      int     // number of recipients if known, used for the ETA
+	Rate       float64 // messages per second, defaults to 25
+	Workers    int     // concurrent requests, defaults to 8
+	MaxRetries int     // retries of network and server errors, defaults to 3
+	// MaxRateLimitRetries bounds the retries of a chat Telegram keeps
+	// rate limiting, the chat then counts as failed. Defaults to 10.
+	MaxRateLimitRetries int
+
+	// OnBlocked is called for chats that can't be messaged anymore: the
+	// user blocked the bot or was deleted, or the bot left the chat.
+	OnBlocked func(chatID int64, err error)
+	// OnFailed is called for chats the message couldn't be sent to for
+	// other reasons.
+	OnFailed func(chatID int64, err error)
+	// OnProgress is called every ProgressInterval, which defaults to 5s,
+	// and when the broadcast stops.
+	OnProgress       func(progress BroadcastProgress)
+	ProgressInterval time.Duration
+}
+
+// BroadcastProgress is the state of a broadcast, saved to the store.
+type BroadcastProgress struct {
+	ID string `json:"id"`
+	// Offset counts the recipients handled in order, a resumed broadcast
+	// skips them along with the ones in Handled
+	Offset    int       `json:"offset"`
+	Handled   []int     `json:"handled,omitempty"` // indexes of recipients handled past Offset
+	Sent      int       `json:"sent"`
+	Blocked   int       `json:"blocked"`
+	Failed    int       `json:"failed"`
+	Total     int       `json:"total,omitempty"`
+	Done      bool      `json:"done"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// pace of the current run
+	runStartedAt time.Time
+	runStart     int
+}
+
+// Processed counts the recipients handled so far.
+func (p BroadcastProgress) Processed() int {
+	return p.Sent + p.Blocked + p.Failed
+}
+
+// ETA estimates the time left from the pace of the current run. It is zero
+// when Total is unknown or nothing was sent yet.
+func (p BroadcastProgress) ETA() time.Duration {
+	done := p.Processed() - p.runStart
+	left := p.Total - p.Processed()
+	if done <= 0 || left <= 0 {
+		return 0
+	}
+	elapsed := p.UpdatedAt.Sub(p.runStartedAt)
+	return time.Duration(float64(elapsed) / float64(done) * float64(left))
+}
+
+// Broadcast sends a message to every recipient, respecting Telegram's rate
+// limits. Rate limited requests wait as long as Telegram asks, network and
+// server errors are retried with backoff. Chats still failing after the
+// retries count as failed.
+//
+// When ctx is cancelled or the recipients fail, the progress is saved and the
+// error returned; calling Broadcast again with the same ID resumes the
+// broadcast. Chats handled after the last save may get the message twice,
+// messages are delivered at least once.
+func (b *Bot) Broadcast(ctx context.Context, recipients Recipients, opts BroadcastOptions) (BroadcastProgress, error) {
+	if opts.Message == nil {
+		return BroadcastProgress{}, &BotError{Code: http.StatusBadRequest, Message: "broadcast has no message"}
+	}
+	if opts.Rate <= 0 {
+		opts.Rate = 25
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 8
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.MaxRateLimitRetries <= 0 {
+		opts.MaxRateLimitRetries = 10
+	}
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = 5 * time.Second
+	}
+
+	bc := &broadcast{
+		bot:     b,
+		opts:    opts,
+		done:    make(map[int]bool),
+		limiter: rateLimiter{interval: time.Duration(float64(time.Second) / opts.Rate)},
+	}
+	if err := bc.load(ctx); err != nil {
+		return BroadcastProgress{}, err
+	}
+	if bc.progress.Done {
+		return bc.progress, nil
+	}
+	b.logger.Info("Broadcast started", "broadcast", opts.ID, "offset", bc.progress.Offset, "total", bc.progress.Total)
+
+	err := bc.run(ctx, recipients)
+
+	progress := bc.report(context.WithoutCancel(ctx))
+	if err == nil {
+		err = bc.saveErr
+	}
+	b.logger.Info("Broadcast stopped", "broadcast", opts.ID, "sent", progress.Sent,
+		"blocked", progress.Blocked, "failed", progress.Failed, "done", progress.Done, "error", err)
+	return progress, err
+}
+
+type broadcast struct {
+	bot     *Bot
+	opts    BroadcastOptions
+	limiter rateLimiter
+
+	mu       sync.Mutex
+	progress BroadcastProgress
+	done     map[int]bool // handled recipients past the offset
+	saveErr  error
+}
+
+type broadcastJob struct {
+	index  int
+	chatID int64
+}
+
+func (bc *broadcast) load(ctx context.Context) error {
+	now := time.Now()
+	bc.progress = BroadcastProgress{ID: bc.opts.ID, StartedAt: now}
+	if bc.opts.Store != nil && bc.opts.ID != "" {
+		saved, err := bc.opts.Store.LoadBroadcast(ctx, bc.opts.ID)
+		if err != nil {
+			return &BotError{Code: http.StatusInternalServerError, Message: "failed to load broadcast progress", Err: err}
+		}
+		if saved != nil {
+			bc.progress = *saved
+		}
+	}
+	for _, index := range bc.progress.Handled {
+		bc.done[index] = true
+	}
+	if bc.opts.Total > 0 {
+		bc.progress.Total = bc.opts.Total
+	}
+	bc.progress.UpdatedAt = now
+	bc.progress.runStartedAt = now
+	bc.progress.runStart = bc.progress.Processed()
+	return nil
+}
+
+// run feeds the recipients to the workers until they are exhausted or ctx
+// is done.
+func (bc *broadcast) run(ctx context.Context, recipients Recipients) error {
+	// skip the recipients handled before a restart
+	for i := 0; i < bc.progress.Offset; i++ {
+		_, ok, err := recipients.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			bc.progress.Done = true
+			return nil
+		}
+	}
+
+	jobs := make(chan broadcastJob)
+	var wg sync.WaitGroup
+	for i := 0; i < bc.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				err := bc.send(ctx, job.chatID)
+				if err != nil && ctx.Err() != nil {
+					// left for a resumed broadcast
+					continue
+				}
+				bc.finish(job, err)
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(bc.opts.ProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				bc.report(ctx)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	err := bc.feed(ctx, recipients, jobs)
+	close(jobs)
+	wg.Wait()
+	close(stop)
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err == nil {
+		bc.mu.Lock()
+		bc.progress.Done = true
+		bc.mu.Unlock()
+	}
+	return err
+}
+
+func (bc *broadcast) feed(ctx context.Context, recipients Recipients, jobs chan<- broadcastJob) error {
+	for index := bc.progress.Offset; ; index++ {
+		chatID, ok, err := recipients.Next(ctx)
+		if err != nil || !ok {
+			return err
+		}
+		bc.mu.Lock()
+		handled := bc.done[index]
+		bc.mu.Unlock()
+		if handled {
+			continue
+		}
+		select {
+		case jobs <- broadcastJob{index: index, chatID: chatID}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// send delivers the message to a chat, waiting out rate limits and retrying
+// network and server errors, both a limited number of times.
+func (bc *broadcast) send(ctx context.Context, chatID int64) error {
+	call, err := bc.opts.Message(chatID)
+	if err != nil {
+		return err
+	}
+
+	for retries, limited := 0, 0; ; {
+		if err := bc.limiter.wait(ctx); err != nil {
+			return err
+		}
+		_, err := bc.bot.callAPI(ctx, call.Method, call.Params)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		if wait, ok := RetryAfter(err); ok {
+			if limited >= bc.opts.MaxRateLimitRetries {
+				return err
+			}
+			limited++
+			bc.bot.metrics.RateLimitWait(call.Method, wait)
+			bc.limiter.pause(wait)
+		} else if isTransient(err) && retries < bc.opts.MaxRetries {
+			if err := sleep(ctx, time.Second<<retries); err != nil {
+				return err
+			}
+			retries++
+		} else {
+			return err
+		}
+		bc.bot.metrics.APIRetry(call.Method)
+	}
+}
+
+func (bc *broadcast) finish(job broadcastJob, err error) {
+	bc.mu.Lock()
+	switch {
+	case err == nil:
+		bc.progress.Sent++
+	case isUnreachable(err):
+		bc.progress.Blocked++
+	default:
+		bc.progress.Failed++
+	}
+	bc.done[job.index] = true
+	for bc.done[bc.progress.Offset] {
+		delete(bc.done, bc.progress.Offset)
+		bc.progress.Offset++
+	}
+	bc.mu.Unlock()
+
+	switch {
+	case err == nil:
+	case isUnreachable(err):
+		if bc.opts.OnBlocked != nil {
+			bc.opts.OnBlocked(job.chatID, err)
+		}
+	default:
+		bc.bot.logger.Warn("Broadcast message failed", "broadcast", bc.opts.ID, "chat_id", job.chatID, "error", err)
+		if bc.opts.OnFailed != nil {
+			bc.opts.OnFailed(job.chatID, err)
+		}
+	}
+}
+
+// report saves the progress and passes it to OnProgress.
+func (bc *broadcast) report(ctx context.Context) BroadcastProgress {
+	bc.mu.Lock()
+	bc.progress.UpdatedAt = time.Now()
+	bc.progress.Handled = bc.progress.Handled[:0]
+	for index := range bc.done {
+		bc.progress.Handled = append(bc.progress.Handled, index)
+	}
+	sort.Ints(bc.progress.Handled)
+	progress := bc.progress
+	progress.Handled = append([]int(nil), bc.progress.Handled...)
+	bc.mu.Unlock()
+
+	if bc.opts.Store != nil && bc.opts.ID != "" {
+		if err := bc.opts.Store.SaveBroadcast(ctx, progress); err != nil {
+			bc.bot.logger.Error("Failed to save broadcast progress", "broadcast", bc.opts.ID, "error", err)
+			bc.mu.Lock()
+			bc.saveErr = &BotError{Code: http.StatusInternalServerError, Message: "failed to save broadcast progress", Err: err}
+			bc.mu.Unlock()
+		}
+	}
+	if bc.opts.OnProgress != nil {
+		bc.opts.OnProgress(progress)
+	}
+	return progress
+}
+
+// isUnreachable reports whether err means the chat can't be messaged
+// anymore.
+func isUnreachable(err error) bool {
+	return errors.Is(err, ErrBotBlocked) || errors.Is(err, ErrUserDeactivated) ||
+		errors.Is(err, ErrBotKicked) || errors.Is(err, ErrChatNotFound)
+}
+
+// isTransient reports whether a failed call may succeed when repeated.
+func isTransient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= 500
+	}
+	var botErr *BotError
+	return errors.As(err, &botErr) && botErr.Code == http.StatusServiceUnavailable
+}
+
+// rateLimiter spaces out calls made from several goroutines.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// wait blocks until the caller's turn.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	at := l.next
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+	return sleep(ctx, time.Until(at))
+}
+
+// pause holds back calls for d.
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.next) {
+		l.next = until
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,112 @@
+package tgx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BroadcastStore persists the progress of broadcasts so they can be resumed
+// after a restart.
+type BroadcastStore interface {
+	// LoadBroadcast returns the saved progress, nil if there is none.
+	LoadBroadcast(ctx context.Context, id string) (*BroadcastProgress, error)
+	SaveBroadcast(ctx context.Context, progress BroadcastProgress) error
+}
+
+// MemoryBroadcastStore keeps progress in memory, broadcasts can be resumed
+// within the process only.
+type MemoryBroadcastStore struct {
+	mu       sync.Mutex
+	progress map[string]BroadcastProgress
+}
+
+func NewMemoryBroadcastStore() *MemoryBroadcastStore {
+	return &MemoryBroadcastStore{progress: make(map[string]BroadcastProgress)}
+}
+
+func (s *MemoryBroadcastStore) LoadBroadcast(_ context.Context, id string) (*BroadcastProgress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	progress, ok := s.progress[id]
+	if !ok {
+		return nil, nil
+	}
+	return &progress, nil
+}
+
+func (s *MemoryBroadcastStore) SaveBroadcast(_ context.Context, progress BroadcastProgress) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress[progress.ID] = progress
+	return nil
+}
+
+// FileBroadcastStore keeps the progress of each broadcast in a JSON file in
+// a directory.
+type FileBroadcastStore struct {
+	dir string
+}
+
+func NewFileBroadcastStore(dir string) *FileBroadcastStore {
+	return &FileBroadcastStore{dir: dir}
+}
+
+func (s *FileBroadcastStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+".json")
+}
+
+func (s *FileBroadcastStore) LoadBroadcast(_ context.Context, id string) (*BroadcastProgress, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var progress BroadcastProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+func (s *FileBroadcastStore) SaveBroadcast(_ context.Context, progress BroadcastProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path(progress.ID), data)
+}
+
+// writeFileAtomic replaces the file at path with data, readers never see a
+// partly written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
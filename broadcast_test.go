@@ -0,0 +1,220 @@
+package tgx_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func broadcastOptions(id string) tgx.BroadcastOptions {
+	return tgx.BroadcastOptions{
+		ID:      id,
+		Message: tgx.BroadcastText(&tgx.SendMessageRequest{Text: "news"}),
+		Rate:    1000,
+		Workers: 1,
+	}
+}
+
+// chatsMessaged returns the chats sent a message, in order.
+func chatsMessaged(srv *tgxtest.Server) []int64 {
+	var chats []int64
+	for _, call := range srv.CallsTo("sendMessage") {
+		chats = append(chats, call.Int("chat_id"))
+	}
+	return chats
+}
+
+func TestBroadcast(t *testing.T) {
+	bot, srv := newTestBot(t)
+	srv.Handle("sendMessage", func(call tgxtest.Call) tgxtest.Response {
+		switch call.Int("chat_id") {
+		case 2:
+			return tgxtest.Forbidden()
+		case 3:
+			return tgxtest.BadRequest("message text is empty")
+		}
+		return tgxtest.OK(map[string]interface{}{"message_id": 1, "chat": map[string]interface{}{"id": call.Int("chat_id")}})
+	})
+
+	var mu sync.Mutex
+	var blocked, failed []int64
+	opts := broadcastOptions("news")
+	opts.Total = 4
+	opts.OnBlocked = func(chatID int64, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		blocked = append(blocked, chatID)
+	}
+	opts.OnFailed = func(chatID int64, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, chatID)
+	}
+	var last tgx.BroadcastProgress
+	opts.OnProgress = func(p tgx.BroadcastProgress) { last = p }
+
+	progress, err := bot.Broadcast(context.Background(), tgx.RecipientList(1, 2, 3, 4), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Sent != 2 || progress.Blocked != 1 || progress.Failed != 1 || !progress.Done {
+		t.Errorf("progress = %+v", progress)
+	}
+	if progress.Offset != 4 || len(progress.Handled) != 0 || progress.Processed() != 4 {
+		t.Errorf("offset = %d, handled = %v", progress.Offset, progress.Handled)
+	}
+	if len(blocked) != 1 || blocked[0] != 2 || len(failed) != 1 || failed[0] != 3 {
+		t.Errorf("blocked = %v, failed = %v", blocked, failed)
+	}
+	if last.Processed() != 4 {
+		t.Errorf("last reported progress = %+v", last)
+	}
+}
+
+func TestBroadcastRetriesServerErrors(t *testing.T) {
+	bot, srv := newTestBot(t)
+	srv.Queue("sendMessage", tgxtest.Error(502, "Bad Gateway"))
+
+	opts := broadcastOptions("")
+	opts.MaxRetries = 1
+	progress, err := bot.Broadcast(context.Background(), tgx.RecipientList(1), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Sent != 1 || len(srv.CallsTo("sendMessage")) != 2 {
+		t.Errorf("progress = %+v, calls = %v", progress, chatsMessaged(srv))
+	}
+}
+
+// A chat Telegram keeps rate limiting is given up on and saved as failed
+// instead of holding up the broadcast forever.
+func TestBroadcastRateLimitRetriesCapped(t *testing.T) {
+	bot, srv := newTestBot(t)
+	srv.Handle("sendMessage", func(call tgxtest.Call) tgxtest.Response {
+		if call.Int("chat_id") == 1 {
+			return tgxtest.TooManyRequests(0)
+		}
+		return tgxtest.OK(map[string]interface{}{"message_id": 1})
+	})
+	store := tgx.NewMemoryBroadcastStore()
+
+	var failedErr error
+	opts := broadcastOptions("limited")
+	opts.Store = store
+	opts.MaxRateLimitRetries = 2
+	opts.OnFailed = func(chatID int64, err error) { failedErr = err }
+
+	progress, err := bot.Broadcast(context.Background(), tgx.RecipientList(1, 2), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(srv.CallsTo("sendMessage")); n != 4 {
+		t.Errorf("%d calls, want 3 to the limited chat and 1 to the other", n)
+	}
+	if !errors.Is(failedErr, tgx.ErrTooManyRequests) {
+		t.Errorf("OnFailed got %v", failedErr)
+	}
+	if progress.Failed != 1 || progress.Sent != 1 || !progress.Done {
+		t.Errorf("progress = %+v", progress)
+	}
+	saved, err := store.LoadBroadcast(context.Background(), "limited")
+	if err != nil || saved == nil || saved.Failed != 1 || saved.Offset != 2 || !saved.Done {
+		t.Errorf("saved progress = %+v, %v", saved, err)
+	}
+}
+
+func TestBroadcastResume(t *testing.T) {
+	bot, srv := newTestBot(t)
+	store := tgx.NewFileBroadcastStore(t.TempDir())
+
+	// stop while the third chat is being sent to
+	ctx, cancel := context.WithCancel(context.Background())
+	srv.Handle("sendMessage", func(call tgxtest.Call) tgxtest.Response {
+		if call.Int("chat_id") == 3 {
+			cancel()
+		}
+		return tgxtest.OK(map[string]interface{}{"message_id": 1})
+	})
+
+	opts := broadcastOptions("weekly/news")
+	opts.Store = store
+	progress, err := bot.Broadcast(ctx, tgx.RecipientList(1, 2, 3, 4, 5), opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if progress.Done || progress.Sent < 2 || progress.Offset != progress.Sent {
+		t.Errorf("progress after stopping = %+v", progress)
+	}
+	sent := progress.Sent
+
+	saved, err := store.LoadBroadcast(context.Background(), "weekly/news")
+	if err != nil || saved == nil || saved.Offset != sent {
+		t.Fatalf("saved progress = %+v, %v", saved, err)
+	}
+
+	srv.Reset()
+	srv.Handle("sendMessage", func(call tgxtest.Call) tgxtest.Response {
+		return tgxtest.OK(map[string]interface{}{"message_id": 1})
+	})
+	progress, err = bot.Broadcast(context.Background(), tgx.RecipientList(1, 2, 3, 4, 5), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !progress.Done || progress.Sent != 5 {
+		t.Errorf("progress after resuming = %+v", progress)
+	}
+	chats := chatsMessaged(srv)
+	if len(chats) != 5-sent || chats[0] != int64(sent+1) {
+		t.Errorf("resumed broadcast messaged %v", chats)
+	}
+
+	// a finished broadcast isn't sent again
+	srv.Reset()
+	if _, err := bot.Broadcast(context.Background(), tgx.RecipientList(1, 2, 3, 4, 5), opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.Calls()) != 0 {
+		t.Errorf("finished broadcast messaged %v", chatsMessaged(srv))
+	}
+}
+
+func TestBroadcastWithoutMessage(t *testing.T) {
+	bot, _ := newTestBot(t)
+	if _, err := bot.Broadcast(context.Background(), tgx.RecipientList(1), tgx.BroadcastOptions{}); !isBadRequest(err) {
+		t.Errorf("err = %v, want a bad request", err)
+	}
+}
+
+func TestBroadcastProgressETA(t *testing.T) {
+	var p tgx.BroadcastProgress
+	if p.ETA() != 0 {
+		t.Error("ETA without progress isn't zero")
+	}
+
+	bot, _ := newTestBot(t)
+	opts := broadcastOptions("")
+	opts.Total = 10
+	opts.Rate = 100
+	var mid tgx.BroadcastProgress
+	opts.ProgressInterval = 25 * time.Millisecond
+	opts.OnProgress = func(p tgx.BroadcastProgress) {
+		if p.Processed() > 0 && p.Processed() < p.Total && mid.Processed() == 0 {
+			mid = p
+		}
+	}
+	if _, err := bot.Broadcast(context.Background(), tgx.RecipientList(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), opts); err != nil {
+		t.Fatal(err)
+	}
+	if mid.Processed() == 0 {
+		t.Fatal("no progress reported mid-way")
+	}
+	// 10ms per message
+	if eta := mid.ETA(); eta <= 0 || eta > time.Second {
+		t.Errorf("ETA with %d of 10 sent = %v", mid.Processed(), eta)
+	}
+}
@@ -1,7 +1,9 @@
 package tgx
 
 import (
+	"context"
 	"strings"
+	"time"
 
 	"github.com/harshyadavone/tgx/models"
 )
@@ -10,44 +12,70 @@ func (b *Bot) OnCallback(data string, handler callbackHandler) {
 	b.callbackHandlers[data] = handler
 }
 
-func (b *Bot) handleCallbackQuery(cb *models.CallbackQuery) error {
+// handleCallbackQuery runs the handler registered for the query's data,
+// its error goes to the error handler.
+func (b *Bot) handleCallbackQuery(reqCtx context.Context, update *models.Update, reply *webhookReply) {
+	cb := update.CallbackQuery
 	ctx := &CallbackContext{
-		QueryID:  cb.ID,
-		Data:     cb.Data,
-		Message:  cb.Message,
-		UserID:   cb.From.Id,
-		Username: cb.From.Username,
-		bot:      b,
+		QueryID:      cb.ID,
+		Data:         cb.Data,
+		Message:      cb.Message,
+		UserID:       cb.From.Id,
+		Username:     cb.From.Username,
+		bot:          b,
+		webhookReply: reply,
+		reqCtx:       reqCtx,
+		update:       update,
 	}
 
 	// check for exact match
 	if handler, ok := b.callbackHandlers[cb.Data]; ok {
-		ctx.bot.logger.Debug("callback handler called")
-		if err := handler(ctx); err != nil {
-			ctx.bot.logger.Error("error in calling handler %w: ", err)
-			return err
-		}
-		return nil
+		ctx.bot.logger.Debug("Callback handler called", "data", cb.Data)
+		b.runCallback(ctx, cb.Data, handler)
+		return
 	}
 
 	// fallback: for prefix
 	for data, handler := range b.callbackHandlers {
 		if strings.HasPrefix(cb.Data, data) {
-			ctx.bot.logger.Debug("callback handler called for prfix: %s", data)
-			if err := handler(ctx); err != nil {
-				ctx.bot.logger.Error("error in calling handler %w: ", err)
-				return err
-			}
-			return nil
+			ctx.bot.logger.Debug("Callback handler called for prefix", "prefix", data, "data", cb.Data)
+			b.runCallback(ctx, data, handler)
+			return
 		}
 	}
 
-	ctx.bot.logger.Warn("No callback handler found for data: %s", cb.Data)
-	return nil
+	ctx.bot.logger.Warn("No callback handler found", "data", cb.Data)
+}
+
+// runCallback runs the handler registered for key and reports it to the
+// bot's tracer and metrics.
+func (b *Bot) runCallback(ctx *CallbackContext, key string, handler callbackHandler) {
+	name := "callback:" + key
+	parent := ctx.reqCtx
+	var span Span
+	ctx.reqCtx, span = b.tracer.Start(ctx.Context(), SpanHandler, Attr(AttrHandler, name))
+	start := time.Now()
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = b.recovered(ctx.update, r)
+		}
+		if err != nil {
+			b.handleError(b.errorContext(ctx.reqCtx, ctx.update, ctx.webhookReply, name), nil, err)
+		}
+		b.metrics.HandlerDone(name, time.Since(start), err)
+		if err != nil {
+			span.RecordError(err)
+		}
+		span.End()
+		ctx.reqCtx = parent
+	}()
+
+	err = handler(ctx)
 }
 
 func (ctx *CallbackContext) AnswerCallback(opts *CallbackAnswerOptions) error {
-	ctx.bot.logger.Info("Answering callback query")
+	ctx.bot.logger.Debug("Answering callback query", "query_id", ctx.QueryID)
 	payload := map[string]interface{}{
 		"callback_query_id": ctx.QueryID,
 	}
@@ -78,8 +106,8 @@ func (ctx *CallbackContext) EditMessage(newText string, opts *EditMessageOptions
 	}
 
 	if opts != nil {
-		if opts.ParseMode == HTML || opts.ParseMode == MarkdownV2 {
-			payload["parse_mode"] = opts.ParseMode
+		if err := setTextFormat(payload, opts.ParseMode, opts.Entities); err != nil {
+			return err
 		}
 		if opts.DisableWebPagePreview {
 			payload["disable_web_page_preview"] = opts.DisableWebPagePreview
@@ -107,8 +135,8 @@ func (ctx *CallbackContext) Reply(text string, opts *SendMessageRequest) error {
 	}
 
 	if opts != nil {
-		if opts.ParseMode == HTML || opts.ParseMode == MarkdownV2 {
-			payload["parse_mode"] = opts.ParseMode
+		if err := setTextFormat(payload, opts.ParseMode, opts.Entities); err != nil {
+			return err
 		}
 		if opts.ReplyMarkup != nil {
 			payload["reply_markup"] = opts.ReplyMarkup
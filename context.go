@@ -1,30 +1,43 @@
 package tgx
 
-import "github.com/harshyadavone/tgx/models"
+import (
+	"context"
+
+	"github.com/harshyadavone/tgx/models"
+)
 
 type Context struct {
-	Text      string
-	Photo     []*models.PhotoSize
-	Video     *models.Video
-	Voice     *models.Voice
-	Document  *models.Document
-	Sticker   *models.Sticker
-	Animation *models.Animation
-	Audio     *models.Audio
-	VideoNote *models.VideoNote
-	Args      []string
-	UserID    int64
-	Username  string
-	MessageId int64
-	ChatID    int64
-	bot       *Bot
+	Text            string
+	Entities        []models.MessageEntity
+	Caption         string
+	CaptionEntities []models.MessageEntity
+	Photo           []*models.PhotoSize
+	Video           *models.Video
+	Voice           *models.Voice
+	Document        *models.Document
+	Sticker         *models.Sticker
+	Animation       *models.Animation
+	Audio           *models.Audio
+	VideoNote       *models.VideoNote
+	Args            []string
+	UserID          int64
+	Username        string
+	MessageId       int64
+	ChatID          int64
+	bot             *Bot
+	webhookReply    *webhookReply
+	reqCtx          context.Context
+	update          *models.Update
 }
 
 type CallbackContext struct {
-	QueryID  string
-	Data     string
-	Message  *models.Message
-	UserID   int64
-	Username string
-	bot      *Bot
+	QueryID      string
+	Data         string
+	Message      *models.Message
+	UserID       int64
+	Username     string
+	bot          *Bot
+	webhookReply *webhookReply
+	reqCtx       context.Context
+	update       *models.Update
 }
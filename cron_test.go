@@ -0,0 +1,82 @@
+package tgx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2026, 10, 14, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 14, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)},
+		{"30 8-18/2 * * *", time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * sat,sun", time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// either day field may match when both are restricted
+		{"0 0 20 * fri", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		// a stepped * doesn't count as restricted: odd days that are Mondays
+		{"0 0 */2 * 1", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 10, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", from.Add(90 * time.Minute)},
+		// no such day
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		c, err := parseCron(tt.spec)
+		if err != nil {
+			t.Errorf("parseCron(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := c.next(from); !got.Equal(tt.want) {
+			t.Errorf("next(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+5:30", 5*3600+1800)
+	c, err := parseCron("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := c.next(time.Date(2026, 10, 14, 10, 0, 0, 0, loc))
+	if want := time.Date(2026, 10, 15, 9, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("next = %v, want %v", got, want)
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"x * * * *",
+		"* * * * funday",
+		"@every 10ms",
+		"@every soon",
+		"@sometimes",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("parseCron(%q) succeeded", spec)
+		}
+	}
+}
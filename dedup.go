@@ -0,0 +1,108 @@
+package tgx
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// UpdateStore remembers recently received update ids so redelivered updates
+// can be dropped. Implement it on a shared store (e.g. Redis SETNX with a
+// TTL) when several replicas receive updates for the same bot.
+type UpdateStore interface {
+	// MarkSeen records the id and reports whether it was already recorded.
+	MarkSeen(ctx context.Context, updateID int) (bool, error)
+	// Forget removes the id, used when an update was not accepted and
+	// Telegram will deliver it again.
+	Forget(ctx context.Context, updateID int) error
+}
+
+// UseUpdateStore drops updates already recorded in store before they are
+// dispatched.
+func (b *Bot) UseUpdateStore(store UpdateStore) {
+	b.updateStore = store
+}
+
+// DeduplicateUpdates drops updates whose id was among the last size ids
+// received by this process.
+func (b *Bot) DeduplicateUpdates(size int) {
+	b.UseUpdateStore(NewMemoryUpdateStore(size))
+}
+
+// isDuplicate reports whether the update was already received. Store errors
+// are logged and the update is processed, duplicates are preferred over
+// losing updates.
+func (b *Bot) isDuplicate(ctx context.Context, update *models.Update) bool {
+	if b.updateStore == nil {
+		return false
+	}
+	seen, err := b.updateStore.MarkSeen(ctx, update.UpdateId)
+	if err != nil {
+		b.logger.Error("Failed to check update for duplicates", "update_id", update.UpdateId, "error", err)
+		return false
+	}
+	if seen {
+		b.logger.Warn("Dropped duplicate update", "update_id", update.UpdateId)
+	}
+	return seen
+}
+
+func (b *Bot) forgetUpdate(ctx context.Context, update *models.Update) {
+	if b.updateStore == nil {
+		return
+	}
+	if err := b.updateStore.Forget(ctx, update.UpdateId); err != nil {
+		b.logger.Error("Failed to forget update", "update_id", update.UpdateId, "error", err)
+	}
+}
+
+// MemoryUpdateStore keeps the most recent update ids in memory, evicting the
+// least recently seen once full.
+type MemoryUpdateStore struct {
+	mu    sync.Mutex
+	size  int
+	ids   map[int]*list.Element
+	order *list.List
+}
+
+func NewMemoryUpdateStore(size int) *MemoryUpdateStore {
+	if size <= 0 {
+		size = 1000
+	}
+	return &MemoryUpdateStore{
+		size:  size,
+		ids:   make(map[int]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+func (s *MemoryUpdateStore) MarkSeen(_ context.Context, updateID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.ids[updateID]; ok {
+		s.order.MoveToFront(el)
+		return true, nil
+	}
+
+	s.ids[updateID] = s.order.PushFront(updateID)
+	if s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.ids, oldest.Value.(int))
+	}
+	return false, nil
+}
+
+func (s *MemoryUpdateStore) Forget(_ context.Context, updateID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.ids[updateID]; ok {
+		s.order.Remove(el)
+		delete(s.ids, updateID)
+	}
+	return nil
+}
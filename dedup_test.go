@@ -0,0 +1,128 @@
+package tgx_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+)
+
+func TestMemoryUpdateStore(t *testing.T) {
+	ctx := context.Background()
+	store := tgx.NewMemoryUpdateStore(2)
+
+	for _, step := range []struct {
+		id   int
+		seen bool
+	}{
+		{1, false},
+		{1, true},
+		{2, false},
+		{3, false}, // evicts 1
+		{1, false},
+		{3, true},
+	} {
+		seen, err := store.MarkSeen(ctx, step.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen != step.seen {
+			t.Errorf("MarkSeen(%d) = %v, want %v", step.id, seen, step.seen)
+		}
+	}
+
+	if err := store.Forget(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if seen, _ := store.MarkSeen(ctx, 3); seen {
+		t.Error("forgotten id still seen")
+	}
+}
+
+func TestWebhookDropsDuplicates(t *testing.T) {
+	bot, _ := newTestBot(t)
+	handled := 0
+	bot.OnMessage("Text", func(ctx *tgx.Context) error {
+		handled++
+		return nil
+	})
+	bot.DeduplicateUpdates(10)
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		bot.HandleWebhook(rec, webhookRequest(""))
+		if rec.Code != http.StatusOK {
+			t.Errorf("delivery %d: status = %d, want 200", i, rec.Code)
+		}
+	}
+	if handled != 1 {
+		t.Errorf("handled %d times, want once", handled)
+	}
+}
+
+func messageUpdate(id int) string {
+	return fmt.Sprintf(`{"update_id":%d,"message":{"message_id":%d,"chat":{"id":1,"type":"private"},"from":{"id":1,"first_name":"A"},"text":"hi"}}`, id, id)
+}
+
+func deliver(bot *tgx.Bot, body string) int {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	bot.HandleWebhook(rec, req)
+	return rec.Code
+}
+
+// An update rejected because the queue is full isn't remembered, Telegram's
+// redelivery is processed.
+func TestRejectedUpdateNotSeen(t *testing.T) {
+	bot, _ := newTestBot(t)
+	var mu sync.Mutex
+	handled := make(map[int64]int)
+	started, release := make(chan struct{}, 10), make(chan struct{})
+	bot.OnMessage("Text", func(ctx *tgx.Context) error {
+		started <- struct{}{}
+		<-release
+		mu.Lock()
+		handled[ctx.MessageId]++
+		mu.Unlock()
+		return nil
+	})
+	bot.DeduplicateUpdates(10)
+	bot.UseWorkerPool(tgx.WorkerPoolOptions{Workers: 1, QueueSize: 1, Backpressure: tgx.BackpressureReject})
+
+	if code := deliver(bot, messageUpdate(1)); code != http.StatusOK {
+		t.Fatalf("update 1: status = %d", code)
+	}
+	<-started // the worker is busy with update 1
+	if code := deliver(bot, messageUpdate(2)); code != http.StatusOK {
+		t.Fatalf("update 2: status = %d", code)
+	}
+	if code := deliver(bot, messageUpdate(3)); code != http.StatusServiceUnavailable {
+		t.Fatalf("update 3: status = %d, want 503", code)
+	}
+
+	close(release)
+	if err := bot.StopWorkerPool(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	bot.UseWorkerPool(tgx.WorkerPoolOptions{Workers: 1, QueueSize: 10})
+	for id := 1; id <= 3; id++ {
+		if code := deliver(bot, messageUpdate(id)); code != http.StatusOK {
+			t.Errorf("redelivered update %d: status = %d", id, code)
+		}
+	}
+	if err := bot.StopWorkerPool(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[int64]int{1: 1, 2: 1, 3: 1}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled = %v, want %v", handled, want)
+	}
+}
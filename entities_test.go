@@ -0,0 +1,74 @@
+package tgx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/format"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func newTestBot(t *testing.T) (*tgx.Bot, *tgxtest.Server) {
+	t.Helper()
+	srv := tgxtest.NewServer()
+	t.Cleanup(srv.Close)
+	bot := tgx.NewBot("123:test", "", nil)
+	srv.Attach(bot)
+	srv.SetDirect(true)
+	return bot, srv
+}
+
+func TestReplyFormattedSendsEntities(t *testing.T) {
+	bot, srv := newTestBot(t)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		return ctx.ReplyFormatted(format.New().Text("👋 ").Bold("hi"))
+	})
+
+	if _, err := srv.SendText(tgxtest.User(1), tgxtest.PrivateChat(1), "/start"); err != nil {
+		t.Fatal(err)
+	}
+	call := srv.AssertCalled(t, "sendMessage")
+	var entities []models.MessageEntity
+	if err := call.Decode("entities", &entities); err != nil {
+		t.Fatal(err)
+	}
+	want := models.MessageEntity{Type: models.EntityBold, Offset: 3, Length: 2}
+	if len(entities) != 1 || entities[0] != want {
+		t.Errorf("entities = %+v, want %+v", entities, want)
+	}
+	if call.String("parse_mode") != "" {
+		t.Errorf("parse_mode sent with entities")
+	}
+}
+
+// ParseMode and Entities are rejected together by every method, rather
+// than one of them being dropped.
+func TestParseModeWithEntitiesFails(t *testing.T) {
+	bot, srv := newTestBot(t)
+	entities := []models.MessageEntity{{Type: models.EntityBold, Offset: 0, Length: 2}}
+
+	err := bot.SendMessageWithOpts(&tgx.SendMessageRequest{ChatId: 1, Text: "hi", ParseMode: tgx.HTML, Entities: entities})
+	if !isBadRequest(err) {
+		t.Errorf("SendMessageWithOpts: err = %v, want a 400 error", err)
+	}
+
+	var replyErr error
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		replyErr = ctx.ReplyWithOpts(&tgx.SendMessageRequest{Text: "hi", ParseMode: tgx.MarkdownV2, Entities: entities})
+		return nil
+	})
+	if _, err := srv.SendText(tgxtest.User(1), tgxtest.PrivateChat(1), "/start"); err != nil {
+		t.Fatal(err)
+	}
+	if !isBadRequest(replyErr) {
+		t.Errorf("ReplyWithOpts: err = %v, want a 400 error", replyErr)
+	}
+	srv.AssertNotCalled(t, "sendMessage")
+}
+
+func isBadRequest(err error) bool {
+	var botErr *tgx.BotError
+	return errors.As(err, &botErr) && botErr.Code == 400
+}
@@ -1,13 +1,131 @@
 package tgx
 
+import (
+	"context"
+	"errors"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// UpdateErrorHandler handles errors of handlers for any kind of update.
+type UpdateErrorHandler func(ctx *ErrorContext, err error)
+
+// ErrorContext describes the update a handler failed on.
+type ErrorContext struct {
+	Update  *models.Update
+	Chat    *models.Chat // nil when the update has no chat, e.g. inline queries
+	User    *models.User // nil when the update has no sender
+	Handler string       // name of the failed handler, e.g. "command:start"
+
+	bot          *Bot
+	webhookReply *webhookReply
+	reqCtx       context.Context
+}
+
+// OnError sets the handler for errors of message and command handlers. It
+// takes precedence over OnUpdateError for them.
 func (b *Bot) OnError(handler ErrorHandler) {
 	b.errorHandler = handler
 }
 
-func (b *Bot) handleError(ctx *Context, err error) {
-	if b.errorHandler != nil {
+// OnUpdateError sets the handler for errors of all handlers, including
+// callback handlers and panics. It gets every error, including the ones
+// telling the chat can't be messaged. DefaultErrorHandler is used until it's
+// set, nil only logs errors.
+func (b *Bot) OnUpdateError(handler UpdateErrorHandler) {
+	b.updateErrorHandler = handler
+}
+
+// DefaultErrorHandler logs the error and tells the user something went
+// wrong: callback queries are answered with an alert, messages with a reply.
+// Other updates, such as inline queries, are only logged. So are errors
+// telling the chat can't be messaged or the bot is rate limited, replying
+// would fail too.
+func DefaultErrorHandler(ctx *ErrorContext, err error) {
+	switch {
+	case IsAPIError(err, 403):
+		ctx.bot.logger.Warn("Bot can't message the chat", "update_id", ctx.Update.UpdateId, "error", err)
+		return
+	case errors.Is(err, ErrTooManyRequests):
+		ctx.bot.logger.Info("Rate limited", "update_id", ctx.Update.UpdateId)
+		return
+	}
+	ctx.bot.logger.Error("Bot error", "update_id", ctx.Update.UpdateId, "handler", ctx.Handler, "error", err)
+
+	const text = "Sorry, something went wrong. Please try again later."
+	switch {
+	case ctx.Update.CallbackQuery != nil:
+		err = ctx.Alert(text)
+	case ctx.Chat != nil:
+		err = ctx.Reply(text)
+	default:
+		return
+	}
+	if err != nil {
+		ctx.bot.logger.Warn("Failed to report error to the user", "update_id", ctx.Update.UpdateId, "error", err)
+	}
+}
+
+// Context returns the context of the update being handled.
+func (ctx *ErrorContext) Context() context.Context {
+	if ctx.reqCtx == nil {
+		return context.Background()
+	}
+	return ctx.reqCtx
+}
+
+// Reply sends text to the chat of the update.
+func (ctx *ErrorContext) Reply(text string) error {
+	if ctx.Chat == nil {
+		return &BotError{Code: 400, Message: "update has no chat to reply to"}
+	}
+	return ctx.makeRequest("sendMessage", map[string]interface{}{
+		"chat_id": ctx.Chat.Id,
+		"text":    text,
+	})
+}
+
+// Alert answers the callback query of the update with an alert.
+func (ctx *ErrorContext) Alert(text string) error {
+	if ctx.Update.CallbackQuery == nil {
+		return &BotError{Code: 400, Message: "update is not a callback query"}
+	}
+	return ctx.makeRequest("answerCallbackQuery", map[string]interface{}{
+		"callback_query_id": ctx.Update.CallbackQuery.ID,
+		"text":              text,
+		"show_alert":        true,
+	})
+}
+
+func (ctx *ErrorContext) makeRequest(method string, params map[string]interface{}) error {
+	if ctx.webhookReply.hold(method, params) {
+		return nil
+	}
+	_, err := ctx.bot.callAPI(ctx.Context(), method, params)
+	return err
+}
+
+func (b *Bot) errorContext(reqCtx context.Context, update *models.Update, reply *webhookReply, handler string) *ErrorContext {
+	return &ErrorContext{
+		Update:       update,
+		Chat:         updateChat(update),
+		User:         updateUser(update),
+		Handler:      handler,
+		bot:          b,
+		webhookReply: reply,
+		reqCtx:       reqCtx,
+	}
+}
+
+// handleError passes the error of a handler to the error handler. ctx is
+// the message context, nil for other updates.
+func (b *Bot) handleError(errCtx *ErrorContext, ctx *Context, err error) {
+	switch {
+	case ctx != nil && b.errorHandler != nil:
 		b.errorHandler(ctx, err)
-	} else {
-		b.logger.Error("Unhandled error: %v", err)
+	case b.updateErrorHandler != nil:
+		b.updateErrorHandler(errCtx, err)
+	default:
+		b.logger.Error("Unhandled error", "update_id", errCtx.Update.UpdateId, "handler", errCtx.Handler, "error", err)
 	}
 }
@@ -0,0 +1,40 @@
+package tgx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// Updates without a chat, such as inline queries, have nobody to tell.
+func TestDefaultErrorHandlerInlineQuery(t *testing.T) {
+	bot := NewBot("123:test", "", nil)
+	var calls []string
+	bot.UseAPIMiddleware(func(next APIFunc) APIFunc {
+		return func(ctx context.Context, call *APICall) (json.RawMessage, error) {
+			calls = append(calls, call.Method)
+			return json.RawMessage("true"), nil
+		}
+	})
+
+	update := &models.Update{UpdateId: 1, InlineQuery: &models.InlineQuery{Id: 7, From: models.User{Id: 42}}}
+	ctx := bot.errorContext(context.Background(), update, nil, "inline")
+	if ctx.Chat != nil || ctx.User == nil || ctx.User.Id != 42 {
+		t.Errorf("chat = %v, user = %v", ctx.Chat, ctx.User)
+	}
+
+	DefaultErrorHandler(ctx, errors.New("failed"))
+
+	if len(calls) != 0 {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+	if err := ctx.Reply("hi"); err == nil {
+		t.Error("reply without a chat succeeded")
+	}
+	if err := ctx.Alert("hi"); err == nil {
+		t.Error("alert without a callback query succeeded")
+	}
+}
@@ -0,0 +1,134 @@
+package tgx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+var errHandler = errors.New("handler failed")
+
+func TestDefaultErrorHandlerReplies(t *testing.T) {
+	bot, srv := newTestBot(t)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		return errHandler
+	})
+
+	bot.ProcessUpdate(commandUpdate(1, "/start"))
+
+	call, ok := srv.LastCall("sendMessage")
+	if !ok || call.Int("chat_id") != 42 || call.String("text") != "Sorry, something went wrong. Please try again later." {
+		t.Errorf("reply = %+v", call)
+	}
+}
+
+func TestDefaultErrorHandlerAlerts(t *testing.T) {
+	bot, srv := newTestBot(t)
+	bot.OnCallback("settings", func(ctx *tgx.CallbackContext) error {
+		return errHandler
+	})
+
+	bot.ProcessUpdate(callbackUpdate(1, "settings"))
+
+	call, ok := srv.LastCall("answerCallbackQuery")
+	if !ok || call.String("callback_query_id") != "cb" || call.Params["show_alert"] != true {
+		t.Errorf("answer = %+v", call)
+	}
+	if len(srv.CallsTo("sendMessage")) != 0 {
+		t.Error("the error was sent as a message")
+	}
+}
+
+func TestUpdateErrorHandlerContext(t *testing.T) {
+	bot, _ := newTestBot(t)
+	var got *tgx.ErrorContext
+	var gotErr error
+	bot.OnUpdateError(func(ctx *tgx.ErrorContext, err error) {
+		got, gotErr = ctx, err
+	})
+	bot.OnCallback("settings", func(ctx *tgx.CallbackContext) error {
+		return errHandler
+	})
+
+	update := callbackUpdate(9, "settings:dark")
+	bot.ProcessUpdate(update)
+
+	if got == nil || !errors.Is(gotErr, errHandler) {
+		t.Fatalf("error handler got %v", gotErr)
+	}
+	if got.Update != update || got.Handler != "callback:settings" {
+		t.Errorf("update = %v, handler = %q", got.Update, got.Handler)
+	}
+	if got.Chat == nil || got.Chat.Id != 42 || got.User == nil || got.User.Id != 42 {
+		t.Errorf("chat = %v, user = %v", got.Chat, got.User)
+	}
+}
+
+// OnError keeps handling message errors, the update error handler gets the
+// others.
+func TestOnErrorPrecedence(t *testing.T) {
+	bot, _ := newTestBot(t)
+	var messageErrs, updateErrs int
+	bot.OnError(func(ctx *tgx.Context, err error) { messageErrs++ })
+	bot.OnUpdateError(func(ctx *tgx.ErrorContext, err error) { updateErrs++ })
+	bot.OnCommand("start", func(ctx *tgx.Context) error { return errHandler })
+	bot.OnCallback("settings", func(ctx *tgx.CallbackContext) error { return errHandler })
+
+	bot.ProcessUpdate(commandUpdate(1, "/start"))
+	bot.ProcessUpdate(callbackUpdate(2, "settings"))
+
+	if messageErrs != 1 || updateErrs != 1 {
+		t.Errorf("message errors = %d, update errors = %d", messageErrs, updateErrs)
+	}
+}
+
+// The default error handler doesn't tell the user about errors that mean
+// the chat can't be messaged.
+func TestDefaultErrorHandlerSkipsUnreachableChats(t *testing.T) {
+	for _, resp := range []tgxtest.Response{tgxtest.Forbidden(), tgxtest.TooManyRequests(30)} {
+		bot, srv := newTestBot(t)
+		bot.OnCommand("start", func(ctx *tgx.Context) error {
+			return ctx.Reply("hi")
+		})
+		srv.Queue("sendMessage", resp)
+
+		bot.ProcessUpdate(commandUpdate(1, "/start"))
+
+		if n := len(srv.CallsTo("sendMessage")); n != 1 {
+			t.Errorf("%d messages sent after a %d, want 1", n, resp.ErrorCode)
+		}
+	}
+}
+
+// Custom error handlers decide themselves what to do about a blocked bot.
+func TestUpdateErrorHandlerGetsUnreachableChats(t *testing.T) {
+	bot, srv := newTestBot(t)
+	var got error
+	bot.OnUpdateError(func(ctx *tgx.ErrorContext, err error) { got = err })
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		return ctx.Reply("hi")
+	})
+	srv.Queue("sendMessage", tgxtest.Forbidden())
+
+	bot.ProcessUpdate(commandUpdate(1, "/start"))
+
+	if !tgx.IsAPIError(got, 403) {
+		t.Errorf("error handler got %v, want the 403", got)
+	}
+}
+
+func TestNilUpdateErrorHandlerOnlyLogs(t *testing.T) {
+	bot, srv := newTestBot(t)
+	bot.OnUpdateError(nil)
+	bot.OnCallback("settings", func(ctx *tgx.CallbackContext) error {
+		return errHandler
+	})
+
+	bot.ProcessUpdate(callbackUpdate(1, "settings"))
+
+	if calls := srv.Calls(); len(calls) != 0 {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
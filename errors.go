@@ -1,6 +1,11 @@
 package tgx
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type BotError struct {
 	Code    int
@@ -15,14 +20,109 @@ func (e *BotError) Error() string {
 	return e.Message
 }
 
+func (e *BotError) Unwrap() error {
+	return e.Err
+}
+
+// Errors the Bot API reports, match them with errors.Is. The *APIError
+// carrying them has the details, e.g. the time to wait after
+// ErrTooManyRequests or the new id after ErrChatMigrated.
+var (
+	ErrUnauthorized          = errors.New("unauthorized")
+	ErrBotBlocked            = errors.New("bot was blocked by the user")
+	ErrBotKicked             = errors.New("bot was kicked from the chat")
+	ErrUserDeactivated       = errors.New("user is deactivated")
+	ErrChatNotFound          = errors.New("chat not found")
+	ErrChatMigrated          = errors.New("group chat was upgraded to a supergroup")
+	ErrMessageNotModified    = errors.New("message is not modified")
+	ErrMessageToEditNotFound = errors.New("message to edit not found")
+	ErrTooManyRequests       = errors.New("too many requests")
+)
+
 type APIError struct {
-	Code        int    `json:"error_code"`
-	Description string `json:"description"`
-	Parameters  struct {
-		RetryAfter int `json:"retry_after,omitempty"`
-	} `json:"parameters,omitempty"`
+	Code        int                `json:"error_code"`
+	Description string             `json:"description"`
+	Parameters  ResponseParameters `json:"parameters,omitempty"`
+}
+
+// ResponseParameters tell why a request failed and how to recover.
+type ResponseParameters struct {
+	MigrateToChatId int64 `json:"migrate_to_chat_id,omitempty"`
+	RetryAfter      int   `json:"retry_after,omitempty"`
 }
 
 func (e *APIError) Error() string {
 	return fmt.Sprintf("Telegram API error (code: %d): %s", e.Code, e.Description)
 }
+
+// Is reports whether the error is of the kind of one of the Err* values.
+func (e *APIError) Is(target error) bool {
+	kind := e.kind()
+	return kind != nil && kind == target
+}
+
+// RetryAfter is how long to wait before repeating a rate limited request.
+func (e *APIError) RetryAfter() time.Duration {
+	return time.Duration(e.Parameters.RetryAfter) * time.Second
+}
+
+// kind classifies the error by code and description, Telegram doesn't
+// report more specific codes.
+func (e *APIError) kind() error {
+	switch {
+	case e.Code == 429:
+		return ErrTooManyRequests
+	case e.Code == 401:
+		return ErrUnauthorized
+	case e.Parameters.MigrateToChatId != 0:
+		return ErrChatMigrated
+	}
+
+	description := strings.ToLower(e.Description)
+	for _, k := range descriptionKinds {
+		if strings.Contains(description, k.text) {
+			return k.err
+		}
+	}
+	return nil
+}
+
+var descriptionKinds = []struct {
+	text string
+	err  error
+}{
+	{"bot was blocked by the user", ErrBotBlocked},
+	{"bot was kicked", ErrBotKicked},
+	{"bot is not a member", ErrBotKicked},
+	{"user is deactivated", ErrUserDeactivated},
+	{"chat not found", ErrChatNotFound},
+	{"group chat was upgraded to a supergroup", ErrChatMigrated},
+	{"message is not modified", ErrMessageNotModified},
+	{"message to edit not found", ErrMessageToEditNotFound},
+}
+
+// RetryAfter returns how long to wait when err is ErrTooManyRequests.
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !errors.Is(apiErr, ErrTooManyRequests) {
+		return 0, false
+	}
+	return apiErr.RetryAfter(), true
+}
+
+// MigratedChatID returns the id of the supergroup a group was upgraded to
+// when err is ErrChatMigrated.
+func MigratedChatID(err error) (int64, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Parameters.MigrateToChatId == 0 {
+		return 0, false
+	}
+	return apiErr.Parameters.MigrateToChatId, true
+}
+
+// IsAPIError reports whether err is, or wraps, a Bot API error with the
+// given code.
+func IsAPIError(err error, errCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == errCode
+}
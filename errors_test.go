@@ -0,0 +1,106 @@
+package tgx_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func TestAPIErrorKinds(t *testing.T) {
+	tests := []struct {
+		err  *tgx.APIError
+		kind error
+	}{
+		{&tgx.APIError{Code: 429, Description: "Too Many Requests: retry after 5"}, tgx.ErrTooManyRequests},
+		{&tgx.APIError{Code: 401, Description: "Unauthorized"}, tgx.ErrUnauthorized},
+		{&tgx.APIError{Code: 403, Description: "Forbidden: bot was blocked by the user"}, tgx.ErrBotBlocked},
+		{&tgx.APIError{Code: 403, Description: "Forbidden: bot was kicked from the group chat"}, tgx.ErrBotKicked},
+		{&tgx.APIError{Code: 403, Description: "Forbidden: bot is not a member of the channel chat"}, tgx.ErrBotKicked},
+		{&tgx.APIError{Code: 403, Description: "Forbidden: user is deactivated"}, tgx.ErrUserDeactivated},
+		{&tgx.APIError{Code: 400, Description: "Bad Request: chat not found"}, tgx.ErrChatNotFound},
+		{&tgx.APIError{Code: 400, Description: "Bad Request: group chat was upgraded to a supergroup chat"}, tgx.ErrChatMigrated},
+		{&tgx.APIError{Code: 400, Description: "Bad Request", Parameters: tgx.ResponseParameters{MigrateToChatId: -100}}, tgx.ErrChatMigrated},
+		{&tgx.APIError{Code: 400, Description: "Bad Request: message is not modified: specified new message content is exactly the same"}, tgx.ErrMessageNotModified},
+		{&tgx.APIError{Code: 400, Description: "Bad Request: message to edit not found"}, tgx.ErrMessageToEditNotFound},
+		{&tgx.APIError{Code: 400, Description: "Bad Request: message text is empty"}, nil},
+	}
+	kinds := []error{
+		tgx.ErrUnauthorized, tgx.ErrBotBlocked, tgx.ErrBotKicked, tgx.ErrUserDeactivated,
+		tgx.ErrChatNotFound, tgx.ErrChatMigrated, tgx.ErrMessageNotModified,
+		tgx.ErrMessageToEditNotFound, tgx.ErrTooManyRequests,
+	}
+	for _, tt := range tests {
+		// wrapped like the bot returns them
+		err := fmt.Errorf("call failed: %w", &tgx.BotError{Code: tt.err.Code, Message: "failed", Err: tt.err})
+		for _, kind := range kinds {
+			if got := errors.Is(err, kind); got != (kind == tt.kind) {
+				t.Errorf("errors.Is(%q, %v) = %v", tt.err.Description, kind, got)
+			}
+		}
+		if !tgx.IsAPIError(err, tt.err.Code) {
+			t.Errorf("IsAPIError(%q, %d) = false", tt.err.Description, tt.err.Code)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := &tgx.BotError{Err: &tgx.APIError{Code: 429, Parameters: tgx.ResponseParameters{RetryAfter: 3}}}
+	if wait, ok := tgx.RetryAfter(err); !ok || wait != 3*time.Second {
+		t.Errorf("RetryAfter = %v, %v", wait, ok)
+	}
+	if _, ok := tgx.RetryAfter(&tgx.APIError{Code: 400}); ok {
+		t.Error("RetryAfter of a 400 is ok")
+	}
+}
+
+func TestMigratedChatID(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &tgx.APIError{Code: 400, Parameters: tgx.ResponseParameters{MigrateToChatId: -1001}})
+	if id, ok := tgx.MigratedChatID(err); !ok || id != -1001 {
+		t.Errorf("MigratedChatID = %d, %v", id, ok)
+	}
+	if _, ok := tgx.MigratedChatID(errors.New("other")); ok {
+		t.Error("MigratedChatID of another error is ok")
+	}
+}
+
+func TestBotErrorUnwrap(t *testing.T) {
+	inner := errors.New("inner")
+	err := &tgx.BotError{Code: 500, Message: "outer", Err: inner}
+	if !errors.Is(err, inner) || err.Error() != "outer: inner" {
+		t.Errorf("err = %v", err)
+	}
+	if (&tgx.BotError{Message: "alone"}).Error() != "alone" {
+		t.Error("message without a cause")
+	}
+}
+
+// JSON and multipart calls fail with the same classified errors.
+func TestCallErrorsClassified(t *testing.T) {
+	bot, srv := newTestBot(t)
+	srv.Queue("sendMessage", tgxtest.Forbidden())
+	srv.Queue("sendDocument", tgxtest.TooManyRequests(7))
+
+	err := bot.SendMessage(42, "hi")
+	if !errors.Is(err, tgx.ErrBotBlocked) {
+		t.Errorf("json call: err = %v, want ErrBotBlocked", err)
+	}
+	var botErr *tgx.BotError
+	if !errors.As(err, &botErr) || botErr.Code != 403 {
+		t.Errorf("json call: err = %#v, want a 403 BotError", err)
+	}
+
+	err = bot.SendDocumentFile(&tgx.SendDocumentRequest{
+		BaseMediaRequest: tgx.BaseMediaRequest{ChatId: 42},
+		Document:         tgx.FileFromBytes("report.txt", []byte("report")),
+	})
+	if !errors.Is(err, tgx.ErrTooManyRequests) {
+		t.Errorf("multipart call: err = %v, want ErrTooManyRequests", err)
+	}
+	if wait, ok := tgx.RetryAfter(err); !ok || wait != 7*time.Second {
+		t.Errorf("multipart call: RetryAfter = %v, %v", wait, ok)
+	}
+}
@@ -0,0 +1,276 @@
+package tgx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// MaxDownloadSize is the largest file bots can download from the public Bot
+// API. A self-hosted server in local mode has no such limit.
+const MaxDownloadSize = 20 << 20
+
+// downloads can take longer than the API timeout, they are bounded by the
+// caller's context instead
+var downloadClient = &http.Client{}
+
+// UseAPIServer points the bot at a self-hosted Bot API server. With local set
+// the server must run with --local, files are then read straight from the
+// paths it returns.
+func (b *Bot) UseAPIServer(apiURL string, local bool) {
+	b.apiURL = strings.TrimSuffix(apiURL, "/")
+	b.localMode = local
+}
+
+// UseMaxDownloadSize limits the size of downloaded files, including files
+// read in local mode. It defaults to MaxDownloadSize, or no limit with a
+// local API server.
+func (b *Bot) UseMaxDownloadSize(size int64) {
+	b.maxDownloadSize = size
+}
+
+// downloadLimit returns the largest file size that can be downloaded, 0 if
+// there is no limit.
+func (b *Bot) downloadLimit() int64 {
+	switch {
+	case b.maxDownloadSize > 0:
+		return b.maxDownloadSize
+	case b.localMode:
+		return 0
+	default:
+		return MaxDownloadSize
+	}
+}
+
+func (b *Bot) GetFile(fileID string) (*models.File, error) {
+	result, err := b.makeAPIRequestWithResult("getFile", map[string]interface{}{
+		"file_id": fileID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var file models.File
+	if err := json.Unmarshal(result, &file); err != nil {
+		return nil, &BotError{
+			Code:    http.StatusInternalServerError,
+			Message: "failed to decode file",
+			Err:     err,
+		}
+	}
+	return &file, nil
+}
+
+// FileURL returns the download link of a file obtained with GetFile. The link
+// contains the bot token and must not be shared.
+func (b *Bot) FileURL(file *models.File) string {
+	return fmt.Sprintf("%s/file/bot%s/%s", b.apiURL, b.token, file.FilePath)
+}
+
+// DownloadFile streams the contents of the file with the given id into w.
+func (b *Bot) DownloadFile(ctx context.Context, fileID string, w io.Writer) error {
+	file, err := b.WithContext(ctx).GetFile(fileID)
+	if err != nil {
+		return err
+	}
+	return b.Download(ctx, file, w)
+}
+
+// Download streams the contents of a file obtained with GetFile into w. Files
+// of unknown size are cut off at the download limit with an error.
+func (b *Bot) Download(ctx context.Context, file *models.File, w io.Writer) error {
+	if err := b.checkFileSize(file.FileSize); err != nil {
+		return err
+	}
+	if file.FilePath == "" {
+		return &BotError{
+			Code:    http.StatusBadRequest,
+			Message: "file has no path, it can't be downloaded",
+		}
+	}
+
+	if b.localMode && filepath.IsAbs(file.FilePath) {
+		return copyLocalFile(file.FilePath, w, b.downloadLimit())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.FileURL(file), nil)
+	if err != nil {
+		return &BotError{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to create request",
+			Err:     err,
+		}
+	}
+
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return &BotError{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Failed to download file",
+			Err:     err,
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &BotError{
+			Code:    resp.StatusCode,
+			Message: "Failed to download file",
+			Err:     fmt.Errorf("unexpected response status: %s", resp.Status),
+		}
+	}
+
+	if err := copyLimited(w, resp.Body, b.downloadLimit()); err != nil {
+		if _, ok := err.(*BotError); ok {
+			return err
+		}
+		return &BotError{
+			Code:    http.StatusServiceUnavailable,
+			Message: "failed to read file contents",
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+func (b *Bot) checkFileSize(size int64) error {
+	if limit := b.downloadLimit(); limit > 0 && size > limit {
+		return fileTooBig(size, limit)
+	}
+	return nil
+}
+
+func fileTooBig(size, limit int64) error {
+	return &BotError{
+		Code:    http.StatusRequestEntityTooLarge,
+		Message: "File is too big to download",
+		Err:     fmt.Errorf("file size %d exceeds the %d bytes download limit", size, limit),
+	}
+}
+
+// copyLimited copies r to w, failing once more than limit bytes were read.
+// The reported size may be unknown or wrong, so the limit is enforced on the
+// contents themselves.
+func copyLimited(w io.Writer, r io.Reader, limit int64) error {
+	if limit <= 0 {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	n, err := io.Copy(w, io.LimitReader(r, limit))
+	if err != nil {
+		return err
+	}
+	if extra, _ := io.CopyN(io.Discard, r, 1); extra > 0 {
+		return fileTooBig(n+extra, limit)
+	}
+	return nil
+}
+
+func copyLocalFile(path string, w io.Writer, limit int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return &BotError{
+			Code:    http.StatusNotFound,
+			Message: "failed to open local file",
+			Err:     err,
+		}
+	}
+	defer f.Close()
+
+	if err := copyLimited(w, f, limit); err != nil {
+		if _, ok := err.(*BotError); ok {
+			return err
+		}
+		return &BotError{
+			Code:    http.StatusInternalServerError,
+			Message: "failed to read file contents",
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// download checks the known size before asking for the file, so oversized
+// files fail without an API call.
+func (ctx *Context) download(c context.Context, kind, fileID string, size int64, w io.Writer) error {
+	if fileID == "" {
+		return &BotError{
+			Code:    http.StatusBadRequest,
+			Message: fmt.Sprintf("message has no %s", kind),
+		}
+	}
+	if err := ctx.bot.checkFileSize(size); err != nil {
+		return err
+	}
+	return ctx.bot.DownloadFile(c, fileID, w)
+}
+
+// DownloadPhoto downloads the largest size of the received photo.
+func (ctx *Context) DownloadPhoto(c context.Context, w io.Writer) error {
+	var largest *models.PhotoSize
+	for _, p := range ctx.Photo {
+		if p != nil && (largest == nil || p.Width*p.Height > largest.Width*largest.Height) {
+			largest = p
+		}
+	}
+	if largest == nil {
+		return ctx.download(c, "photo", "", 0, w)
+	}
+	return ctx.download(c, "photo", largest.FileId, largest.FileSize, w)
+}
+
+func (ctx *Context) DownloadDocument(c context.Context, w io.Writer) error {
+	if ctx.Document == nil {
+		return ctx.download(c, "document", "", 0, w)
+	}
+	return ctx.download(c, "document", ctx.Document.FileId, ctx.Document.FileSize, w)
+}
+
+func (ctx *Context) DownloadVideo(c context.Context, w io.Writer) error {
+	if ctx.Video == nil {
+		return ctx.download(c, "video", "", 0, w)
+	}
+	return ctx.download(c, "video", ctx.Video.FileId, ctx.Video.FileSize, w)
+}
+
+func (ctx *Context) DownloadVoice(c context.Context, w io.Writer) error {
+	if ctx.Voice == nil {
+		return ctx.download(c, "voice", "", 0, w)
+	}
+	return ctx.download(c, "voice", ctx.Voice.FileId, ctx.Voice.FileSize, w)
+}
+
+func (ctx *Context) DownloadAudio(c context.Context, w io.Writer) error {
+	if ctx.Audio == nil {
+		return ctx.download(c, "audio", "", 0, w)
+	}
+	return ctx.download(c, "audio", ctx.Audio.FileId, ctx.Audio.FileSize, w)
+}
+
+func (ctx *Context) DownloadAnimation(c context.Context, w io.Writer) error {
+	if ctx.Animation == nil {
+		return ctx.download(c, "animation", "", 0, w)
+	}
+	return ctx.download(c, "animation", ctx.Animation.FileId, ctx.Animation.FileSize, w)
+}
+
+func (ctx *Context) DownloadVideoNote(c context.Context, w io.Writer) error {
+	if ctx.VideoNote == nil {
+		return ctx.download(c, "video note", "", 0, w)
+	}
+	return ctx.download(c, "video note", ctx.VideoNote.FileId, ctx.VideoNote.FileSize, w)
+}
+
+func (ctx *Context) DownloadSticker(c context.Context, w io.Writer) error {
+	if ctx.Sticker == nil {
+		return ctx.download(c, "sticker", "", 0, w)
+	}
+	return ctx.download(c, "sticker", ctx.Sticker.FileId, ctx.Sticker.FileSize, w)
+}
@@ -0,0 +1,101 @@
+package tgx_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+)
+
+// newFileServer serves getFile without a file size and the contents at the
+// file path, like Telegram does for some older files.
+func newFileServer(t *testing.T, contents []byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getFile"):
+			fmt.Fprint(w, `{"ok":true,"result":{"file_id":"f","file_unique_id":"f","file_path":"docs/f.bin"}}`)
+		case strings.HasSuffix(r.URL.Path, "/docs/f.bin"):
+			w.Write(contents)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDownloadFile(t *testing.T) {
+	contents := bytes.Repeat([]byte("x"), 1000)
+	srv := newFileServer(t, contents)
+	bot := tgx.NewBot("123:test", "", nil)
+	bot.UseAPIServer(srv.URL, false)
+
+	var buf bytes.Buffer
+	if err := bot.DownloadFile(context.Background(), "f", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), contents) {
+		t.Errorf("downloaded %d bytes, want %d", buf.Len(), len(contents))
+	}
+}
+
+// Files without a known size are cut off at the limit.
+func TestDownloadUnknownSizeLimit(t *testing.T) {
+	srv := newFileServer(t, bytes.Repeat([]byte("x"), 1001))
+	bot := tgx.NewBot("123:test", "", nil)
+	bot.UseAPIServer(srv.URL, false)
+	bot.UseMaxDownloadSize(1000)
+
+	var buf bytes.Buffer
+	err := bot.DownloadFile(context.Background(), "f", &buf)
+	var botErr *tgx.BotError
+	if !errors.As(err, &botErr) || botErr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("err = %v, want a 413 error", err)
+	}
+	if buf.Len() > 1000 {
+		t.Errorf("wrote %d bytes past the limit", buf.Len())
+	}
+}
+
+func TestDownloadKnownSizeLimit(t *testing.T) {
+	bot := tgx.NewBot("123:test", "", nil)
+	file := &models.File{FileId: "f", FilePath: "docs/f.bin", FileSize: tgx.MaxDownloadSize + 1}
+	err := bot.Download(context.Background(), file, &bytes.Buffer{})
+	var botErr *tgx.BotError
+	if !errors.As(err, &botErr) || botErr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("err = %v, want a 413 error", err)
+	}
+}
+
+func TestDownloadLocalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.bin")
+	if err := os.WriteFile(path, []byte("local contents"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	bot := tgx.NewBot("123:test", "", nil)
+	bot.UseAPIServer("http://127.0.0.1:1", true)
+
+	var buf bytes.Buffer
+	file := &models.File{FileId: "f", FilePath: path}
+	if err := bot.Download(context.Background(), file, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "local contents" {
+		t.Errorf("got %q", buf.String())
+	}
+
+	bot.UseMaxDownloadSize(5)
+	if err := bot.Download(context.Background(), file, &bytes.Buffer{}); err == nil {
+		t.Error("local file over the limit downloaded")
+	}
+}
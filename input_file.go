@@ -0,0 +1,101 @@
+package tgx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// ErrFileConsumed is returned when a file created with FileFromReader is sent
+// again, e.g. when a call is retried, after its reader was read.
+var ErrFileConsumed = errors.New("file reader was already consumed")
+
+// InputFile is a file to send. It is either uploaded (from a path, a reader
+// or bytes) or referenced by URL or file_id of a file already on Telegram's
+// servers.
+type InputFile struct {
+	ref    string // file_id or URL
+	path   string
+	data   []byte
+	reader io.Reader
+	name   string
+
+	consumed atomic.Bool // the reader was opened
+}
+
+// FileFromID references a file already stored on Telegram's servers.
+func FileFromID(fileID string) *InputFile {
+	return &InputFile{ref: fileID}
+}
+
+// FileFromURL lets Telegram download the file from url.
+func FileFromURL(url string) *InputFile {
+	return &InputFile{ref: url}
+}
+
+// FileFromPath uploads the file at path, it is opened when the request is sent.
+func FileFromPath(path string) *InputFile {
+	return &InputFile{path: path, name: filepath.Base(path)}
+}
+
+// FileFromBytes uploads data under the given file name.
+func FileFromBytes(name string, data []byte) *InputFile {
+	return &InputFile{data: data, name: name}
+}
+
+// FileFromReader uploads the contents of r under the given file name. The
+// reader is consumed by the first request using it, sending the file again
+// fails with ErrFileConsumed. Use FileFromBytes for files sent repeatedly.
+func FileFromReader(name string, r io.Reader) *InputFile {
+	return &InputFile{reader: r, name: name}
+}
+
+func (f *InputFile) isUpload() bool {
+	return f != nil && f.ref == ""
+}
+
+func (f *InputFile) fileName() string {
+	if f.name == "" {
+		return "file"
+	}
+	return f.name
+}
+
+// replayable reports whether the file can still be sent, a reader is read
+// by one request only.
+func (f *InputFile) replayable() bool {
+	return f == nil || f.reader == nil || !f.consumed.Load()
+}
+
+func (f *InputFile) open() (io.ReadCloser, error) {
+	switch {
+	case f.path != "":
+		return os.Open(f.path)
+	case f.data != nil:
+		return io.NopCloser(bytes.NewReader(f.data)), nil
+	case f.reader != nil:
+		if f.consumed.Swap(true) {
+			return nil, ErrFileConsumed
+		}
+		return io.NopCloser(f.reader), nil
+	default:
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+}
+
+// String returns the file_id or URL. Uploads are shown as attach://<name>,
+// the field they are sent in is chosen when the request is encoded.
+func (f *InputFile) String() string {
+	if f.isUpload() {
+		return "attach://" + f.fileName()
+	}
+	return f.ref
+}
+
+func (f *InputFile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
@@ -0,0 +1,124 @@
+package tgx_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+)
+
+func TestUploadFromPath(t *testing.T) {
+	bot, srv := newTestBot(t)
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(path, []byte("report"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &tgx.SendDocumentRequest{Document: tgx.FileFromPath(path)}
+	req.ChatId = 1
+	req.Caption = "weekly"
+	if err := bot.SendDocument(req); err != nil {
+		t.Fatal(err)
+	}
+
+	call := srv.AssertCalled(t, "sendDocument")
+	if got := string(call.Files["document"]); got != "report" {
+		t.Errorf("document = %q", got)
+	}
+	if call.Int("chat_id") != 1 || call.String("caption") != "weekly" {
+		t.Errorf("form fields = %+v", call.Params)
+	}
+}
+
+func TestUploadByReference(t *testing.T) {
+	bot, srv := newTestBot(t)
+	req := &tgx.SendDocumentRequest{Document: tgx.FileFromID("file-id")}
+	req.ChatId = 1
+	if err := bot.SendDocument(req); err != nil {
+		t.Fatal(err)
+	}
+	call := srv.AssertCalled(t, "sendDocument")
+	if call.String("document") != "file-id" || len(call.Files) != 0 {
+		t.Errorf("document = %q, files = %v", call.String("document"), call.Files)
+	}
+}
+
+// Media group uploads are attached under generated names without touching
+// the caller's files, so the same media can be sent again, concurrently too.
+func TestUploadMediaGroup(t *testing.T) {
+	bot, srv := newTestBot(t)
+	media := []tgx.InputMedia{
+		{Type: "photo", Media: tgx.FileFromBytes("a.jpg", []byte("a"))},
+		{Type: "photo", Media: tgx.FileFromID("existing")},
+		{Type: "video", Media: tgx.FileFromBytes("b.mp4", []byte("b")), Thumbnail: tgx.FileFromBytes("t.jpg", []byte("t"))},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := bot.SendMediaGroup(1, media); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, call := range srv.CallsTo("sendMediaGroup") {
+		var sent []struct {
+			Media     string `json:"media"`
+			Thumbnail string `json:"thumbnail"`
+		}
+		if err := call.Decode("media", &sent); err != nil {
+			t.Fatal(err)
+		}
+		if sent[0].Media != "attach://file0" || sent[1].Media != "existing" ||
+			sent[2].Media != "attach://file1" || sent[2].Thumbnail != "attach://file2" {
+			t.Errorf("media = %+v", sent)
+		}
+		for name, want := range map[string]string{"file0": "a", "file1": "b", "file2": "t"} {
+			if got := string(call.Files[name]); got != want {
+				t.Errorf("%s = %q, want %q", name, got, want)
+			}
+		}
+	}
+	if got := media[0].Media.String(); got != "attach://a.jpg" {
+		t.Errorf("caller's media changed to %q", got)
+	}
+}
+
+// A reader can be sent once, sending it again fails instead of uploading an
+// empty file.
+func TestUploadFromReaderOnce(t *testing.T) {
+	bot, srv := newTestBot(t)
+	file := tgx.FileFromReader("notes.txt", strings.NewReader("notes"))
+	req := &tgx.SendDocumentRequest{Document: file}
+	req.ChatId = 1
+
+	if err := bot.SendDocument(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(srv.AssertCalled(t, "sendDocument").Files["document"]); got != "notes" {
+		t.Errorf("document = %q", got)
+	}
+
+	if err := bot.SendDocument(req); !errors.Is(err, tgx.ErrFileConsumed) {
+		t.Errorf("second send: err = %v, want ErrFileConsumed", err)
+	}
+	srv.AssertCallCount(t, "sendDocument", 1)
+}
+
+func TestUploadMissingFile(t *testing.T) {
+	bot, srv := newTestBot(t)
+	req := &tgx.SendDocumentRequest{Document: tgx.FileFromPath(filepath.Join(t.TempDir(), "missing"))}
+	req.ChatId = 1
+	if err := bot.SendDocument(req); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v, want a not exist error", err)
+	}
+	srv.AssertNotCalled(t, "sendDocument")
+}
@@ -0,0 +1,138 @@
+package tgx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"sync"
+)
+
+// JobStore persists scheduled jobs.
+type JobStore interface {
+	// SaveJob adds the job or replaces the one with the same ID.
+	SaveJob(ctx context.Context, job Job) error
+	DeleteJob(ctx context.Context, id string) error
+	Jobs(ctx context.Context) ([]Job, error)
+}
+
+// MemoryJobStore keeps jobs in memory, they are lost when the process exits.
+type MemoryJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]Job)}
+}
+
+func (s *MemoryJobStore) SaveJob(_ context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MemoryJobStore) DeleteJob(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *MemoryJobStore) Jobs(context.Context) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// FileJobStore keeps jobs in a JSON file, rewritten on every change. It
+// suits a single process with up to a few thousand jobs.
+type FileJobStore struct {
+	path string
+
+	mu     sync.Mutex
+	memory *MemoryJobStore // nil until the file was read
+}
+
+func NewFileJobStore(path string) *FileJobStore {
+	return &FileJobStore{path: path}
+}
+
+func (s *FileJobStore) SaveJob(ctx context.Context, job Job) error {
+	return s.update(func(jobs *MemoryJobStore) error {
+		return jobs.SaveJob(ctx, job)
+	})
+}
+
+func (s *FileJobStore) DeleteJob(ctx context.Context, id string) error {
+	return s.update(func(jobs *MemoryJobStore) error {
+		return jobs.DeleteJob(ctx, id)
+	})
+}
+
+func (s *FileJobStore) Jobs(ctx context.Context) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s.memory.Jobs(ctx)
+}
+
+// update applies a change to a copy of the jobs and keeps it once the file
+// was written, so a failed write changes nothing.
+func (s *FileJobStore) update(change func(jobs *MemoryJobStore) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	updated := &MemoryJobStore{jobs: maps.Clone(s.memory.jobs)}
+	if err := change(updated); err != nil {
+		return err
+	}
+
+	jobs, _ := updated.Jobs(context.Background())
+	data, err := json.Marshal(jobs)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return err
+	}
+	s.memory = updated
+	return nil
+}
+
+func (s *FileJobStore) load() error {
+	if s.memory != nil {
+		return nil
+	}
+	memory := NewMemoryJobStore()
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(data) > 0 {
+		// numbers in call params such as chat ids are kept exact
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var jobs []Job
+		if err := decoder.Decode(&jobs); err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			memory.jobs[job.ID] = job
+		}
+	}
+	s.memory = memory
+	return nil
+}
@@ -0,0 +1,126 @@
+package tgx_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+)
+
+func reminder(id string, chatID int64) tgx.Job {
+	return tgx.Job{
+		ID: id,
+		At: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC),
+		Call: &tgx.APICall{Method: "sendMessage", Params: map[string]interface{}{
+			"chat_id": chatID,
+			"text":    "reminder",
+		}},
+	}
+}
+
+func jobIDs(t *testing.T, store tgx.JobStore) map[string]tgx.Job {
+	t.Helper()
+	jobs, err := store.Jobs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]tgx.Job)
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+	return byID
+}
+
+func TestMemoryJobStore(t *testing.T) {
+	ctx := context.Background()
+	store := tgx.NewMemoryJobStore()
+	store.SaveJob(ctx, reminder("a", 1))
+	store.SaveJob(ctx, reminder("b", 2))
+	store.SaveJob(ctx, reminder("a", 3))
+	store.DeleteJob(ctx, "b")
+
+	jobs := jobIDs(t, store)
+	if len(jobs) != 1 || jobs["a"].Call.Params["chat_id"] != int64(3) {
+		t.Errorf("jobs = %v", jobs)
+	}
+}
+
+func TestFileJobStorePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "jobs", "jobs.json")
+	store := tgx.NewFileJobStore(path)
+	if err := store.SaveJob(ctx, reminder("a", -1001234567890123)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveJob(ctx, reminder("b", 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteJob(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	// as read after a restart
+	jobs := jobIDs(t, tgx.NewFileJobStore(path))
+	job, ok := jobs["a"]
+	if len(jobs) != 1 || !ok {
+		t.Fatalf("jobs = %v", jobs)
+	}
+	if !job.At.Equal(reminder("a", 0).At) || job.Call.Method != "sendMessage" {
+		t.Errorf("job = %+v", job)
+	}
+	// large chat ids survive the round trip
+	if id := job.Call.Params["chat_id"]; id != json.Number("-1001234567890123") {
+		t.Errorf("chat_id = %#v", id)
+	}
+}
+
+func TestFileJobStoreEmpty(t *testing.T) {
+	store := tgx.NewFileJobStore(filepath.Join(t.TempDir(), "missing.json"))
+	if jobs := jobIDs(t, store); len(jobs) != 0 {
+		t.Errorf("jobs = %v", jobs)
+	}
+}
+
+func TestFileJobStoreCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	os.WriteFile(path, []byte("{"), 0o600)
+	if _, err := tgx.NewFileJobStore(path).Jobs(context.Background()); err == nil {
+		t.Error("corrupt file was read")
+	}
+}
+
+// A change that couldn't be written isn't kept in memory either.
+func TestFileJobStoreFailedWrite(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	store := tgx.NewFileJobStore(path)
+	if err := store.SaveJob(ctx, reminder("a", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file can't be replaced by a directory
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "keep"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.SaveJob(ctx, reminder("b", 2)); err == nil {
+		t.Fatal("save succeeded")
+	}
+	if err := store.DeleteJob(ctx, "a"); err == nil {
+		t.Fatal("delete succeeded")
+	}
+	jobs := jobIDs(t, store)
+	if _, ok := jobs["a"]; len(jobs) != 1 || !ok {
+		t.Errorf("jobs after failed writes = %v", jobs)
+	}
+}
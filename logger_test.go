@@ -0,0 +1,110 @@
+package tgx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/logger"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+// logRecords decodes the records written by a slog.JSONHandler.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]any
+		if err := dec.Decode(&record); err != nil {
+			t.Fatalf("invalid log record: %v", err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func findRecord(records []map[string]any, msg string) map[string]any {
+	for _, record := range records {
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	return nil
+}
+
+func TestBotLogsStructuredFields(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.NewSlogLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	bot := tgx.NewBot(tgxtest.Token, "", log)
+	srv := tgxtest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Attach(bot)
+	srv.SetDirect(true)
+	bot.OnMessage("Text", func(ctx *tgx.Context) error {
+		return ctx.Reply("hi")
+	})
+
+	bot.ProcessUpdate(&models.Update{
+		UpdateId: 7,
+		Message: &models.Message{
+			MessageId: 1,
+			Chat:      models.Chat{Id: 42, Type: "private"},
+			From:      models.User{Id: 42},
+			Text:      "hello",
+		},
+	})
+
+	records := logRecords(t, &buf)
+	call := findRecord(records, "API call")
+	if call == nil {
+		t.Fatalf("no API call logged in %v", records)
+	}
+	if call["method"] != "sendMessage" || call["chat_id"] != float64(42) || call["duration"] == nil {
+		t.Errorf("API call record = %v", call)
+	}
+	processed := findRecord(records, "Processed update")
+	if processed == nil || processed["update_id"] != float64(7) || processed["duration"] == nil {
+		t.Errorf("Processed update record = %v", processed)
+	}
+}
+
+func TestNewBotWithoutLogger(t *testing.T) {
+	bot, _ := newTestBot(t)
+	if err := bot.SendMessage(42, "hi"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A failing callback handler's error is logged once, by the error handler.
+func TestCallbackErrorLoggedOnce(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.NewSlogLogger(slog.NewJSONHandler(&buf, nil))
+
+	bot := tgx.NewBot(tgxtest.Token, "", log)
+	srv := tgxtest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Attach(bot)
+	srv.SetDirect(true)
+	bot.OnUpdateError(nil)
+	bot.OnCallback("fail", func(ctx *tgx.CallbackContext) error {
+		return errors.New("boom")
+	})
+
+	bot.ProcessUpdate(callbackUpdate(1, "fail"))
+
+	var errs []map[string]any
+	for _, record := range logRecords(t, &buf) {
+		if record["level"] == "ERROR" {
+			errs = append(errs, record)
+		}
+	}
+	if len(errs) != 1 || errs[0]["error"] != "boom" {
+		t.Errorf("error records = %v, want the error logged once", errs)
+	}
+}
@@ -2,6 +2,7 @@ package tgx
 
 import (
 	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/format"
 )
 
 func (b *Bot) OnMessage(messageType string, handler Handler) {
@@ -27,8 +28,8 @@ func (ctx *Context) ReplyWithOpts(req *SendMessageRequest) error {
 		"text":    req.Text,
 	}
 
-	if req.ParseMode == HTML || req.ParseMode == MarkdownV2 {
-		payload["parse_mode"] = req.ParseMode
+	if err := setTextFormat(payload, req.ParseMode, req.Entities); err != nil {
+		return err
 	}
 
 	if req.ReplyMarkup != nil {
@@ -57,3 +58,62 @@ func (ctx *Context) ReplyWithInlineKeyboard(text string, buttons [][]models.Inli
 		},
 	})
 }
+
+// ReplyFormatted replies with a message built by the format package, sent as
+// plain text plus entities so no escaping is involved.
+func (ctx *Context) ReplyFormatted(msg *format.Builder) error {
+	text, entities := msg.Entities()
+	return ctx.ReplyWithOpts(&SendMessageRequest{
+		Text:     text,
+		Entities: entities,
+	})
+}
+
+// EntityValues returns the text of the message entities of the given types,
+// falling back to the caption for media messages.
+func (ctx *Context) EntityValues(types ...string) []string {
+	if ctx.Text == "" && ctx.Caption != "" {
+		return format.ExtractEntities(ctx.Caption, ctx.CaptionEntities, types...)
+	}
+	return format.ExtractEntities(ctx.Text, ctx.Entities, types...)
+}
+
+func (ctx *Context) Mentions() []string {
+	return ctx.EntityValues(models.EntityMention, models.EntityTextMention)
+}
+
+func (ctx *Context) Hashtags() []string {
+	return ctx.EntityValues(models.EntityHashtag)
+}
+
+func (ctx *Context) Commands() []string {
+	return ctx.EntityValues(models.EntityBotCommand)
+}
+
+// URLs returns plain urls in the message as well as the targets of text links.
+func (ctx *Context) URLs() []string {
+	text, entities := ctx.Text, ctx.Entities
+	if text == "" && ctx.Caption != "" {
+		text, entities = ctx.Caption, ctx.CaptionEntities
+	}
+
+	var urls []string
+	for _, e := range entities {
+		switch e.Type {
+		case models.EntityURL:
+			urls = append(urls, format.EntityText(text, e))
+		case models.EntityTextLink:
+			urls = append(urls, e.URL)
+		}
+	}
+	return urls
+}
+
+// FormattedText returns the message text rendered for the given parse mode,
+// useful for forwarding formatted text in a new message.
+func (ctx *Context) FormattedText(mode ParseMode) string {
+	if ctx.Text == "" && ctx.Caption != "" {
+		return format.EntitiesTo(string(mode), ctx.Caption, ctx.CaptionEntities)
+	}
+	return format.EntitiesTo(string(mode), ctx.Text, ctx.Entities)
+}
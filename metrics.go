@@ -0,0 +1,75 @@
+package tgx
+
+import (
+	"errors"
+	"time"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// Metrics receives measurements of the bot's activity. Implementations must
+// be safe for concurrent use; pkg/metrics provides one exposing them in the
+// Prometheus text format.
+type Metrics interface {
+	// UpdateReceived counts an update by type, e.g. message or callback_query.
+	UpdateReceived(updateType string)
+	// HandlerDone records a handler run. Handlers are named after what they
+	// were registered for, e.g. command:start, message:Photo or
+	// callback:settings.
+	HandlerDone(handler string, duration time.Duration, err error)
+	// APICall records a Bot API call with the Telegram error code or HTTP
+	// status it ended with, 200 on success and 0 when no response arrived.
+	APICall(method string, status int, duration time.Duration)
+	// APIRetry counts a call being sent again after failing.
+	APIRetry(method string)
+	// RateLimitWait records time spent waiting because of a 429.
+	RateLimitWait(method string, wait time.Duration)
+	// QueueDepth reports the number of updates waiting in the worker pool.
+	QueueDepth(depth int)
+}
+
+// UseMetrics reports the bot's activity to m, nil turns reporting off.
+func (b *Bot) UseMetrics(m Metrics) {
+	if m == nil {
+		m = nopMetrics{}
+	}
+	b.metrics = m
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) UpdateReceived(string)                    {}
+func (nopMetrics) HandlerDone(string, time.Duration, error) {}
+func (nopMetrics) APICall(string, int, time.Duration)       {}
+func (nopMetrics) APIRetry(string)                          {}
+func (nopMetrics) RateLimitWait(string, time.Duration)      {}
+func (nopMetrics) QueueDepth(int)                           {}
+
+// updateType names the kind of an update like the Bot API fields do.
+func updateType(update *models.Update) string {
+	switch {
+	case update.Message != nil:
+		return "message"
+	case update.EditedMessage != nil:
+		return "edited_message"
+	case update.CallbackQuery != nil:
+		return "callback_query"
+	case update.InlineQuery != nil:
+		return "inline_query"
+	default:
+		return "unknown"
+	}
+}
+
+// callStatus returns 200 for a successful call, the Telegram error code of a
+// failed one, or 0 when the call failed without a response from Telegram.
+func callStatus(err error) int {
+	if err == nil {
+		return 200
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return 0
+}
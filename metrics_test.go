@@ -0,0 +1,165 @@
+package tgx_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+// countingMetrics counts the measurements reported to it by name.
+type countingMetrics struct {
+	mu     sync.Mutex
+	counts map[string]int
+	waited time.Duration
+}
+
+func newCountingMetrics() *countingMetrics {
+	return &countingMetrics{counts: make(map[string]int)}
+}
+
+func (m *countingMetrics) add(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[name]++
+}
+
+func (m *countingMetrics) count(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[name]
+}
+
+func (m *countingMetrics) UpdateReceived(updateType string) { m.add("update:" + updateType) }
+
+func (m *countingMetrics) HandlerDone(handler string, _ time.Duration, err error) {
+	m.add("handler:" + handler)
+	if err != nil {
+		m.add("handler_error:" + handler)
+	}
+}
+
+func (m *countingMetrics) APICall(method string, status int, _ time.Duration) {
+	m.add(fmt.Sprintf("api:%s:%d", method, status))
+}
+
+func (m *countingMetrics) APIRetry(method string) { m.add("retry:" + method) }
+
+func (m *countingMetrics) RateLimitWait(method string, wait time.Duration) {
+	m.add("wait:" + method)
+	m.mu.Lock()
+	m.waited += wait
+	m.mu.Unlock()
+}
+
+func (m *countingMetrics) QueueDepth(int) { m.add("queue_depth") }
+
+func commandUpdate(id int, text string) *models.Update {
+	return &models.Update{
+		UpdateId: id,
+		Message: &models.Message{
+			MessageId: int64(id),
+			Chat:      models.Chat{Id: 42, Type: "private"},
+			From:      models.User{Id: 42},
+			Text:      text,
+			Entities:  []models.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(text)}},
+		},
+	}
+}
+
+func TestMetricsUpdatesAndCalls(t *testing.T) {
+	bot, srv := newTestBot(t)
+	m := newCountingMetrics()
+	bot.UseMetrics(m)
+	bot.OnUpdateError(nil)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		return ctx.Reply("hi")
+	})
+	bot.OnCommand("fail", func(ctx *tgx.Context) error {
+		return ctx.Reply("bye")
+	})
+	srv.Queue("sendMessage", tgxtest.OK(nil), tgxtest.BadRequest("chat not found"))
+
+	bot.ProcessUpdate(commandUpdate(1, "/start"))
+	bot.ProcessUpdate(commandUpdate(2, "/fail"))
+
+	want := map[string]int{
+		"update:message":             2,
+		"handler:command:start":      1,
+		"handler:command:fail":       1,
+		"handler_error:command:fail": 1,
+		"api:sendMessage:200":        1,
+		"api:sendMessage:400":        1,
+	}
+	for name, n := range want {
+		if got := m.count(name); got != n {
+			t.Errorf("%s = %d, want %d", name, got, n)
+		}
+	}
+}
+
+func TestMetricsMigrationRetry(t *testing.T) {
+	bot, srv := newTestBot(t)
+	m := newCountingMetrics()
+	bot.UseMetrics(m)
+	bot.RetryMigratedChats(true)
+	srv.Queue("sendMessage", tgxtest.Response{
+		ErrorCode:   400,
+		Description: "Bad Request: group chat was upgraded to a supergroup chat",
+		MigrateTo:   -1001,
+	})
+
+	if err := bot.SendMessage(-1, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.count("retry:sendMessage"); got != 1 {
+		t.Errorf("retries = %d, want 1", got)
+	}
+	if got := m.count("api:sendMessage:400") + m.count("api:sendMessage:200"); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestMetricsBroadcastRateLimit(t *testing.T) {
+	bot, srv := newTestBot(t)
+	m := newCountingMetrics()
+	bot.UseMetrics(m)
+	srv.Queue("sendMessage", tgxtest.TooManyRequests(0))
+
+	progress, err := bot.Broadcast(context.Background(), tgx.RecipientList(42), tgx.BroadcastOptions{
+		Message: tgx.BroadcastText(&tgx.SendMessageRequest{Text: "news"}),
+		Rate:    1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Sent != 1 {
+		t.Errorf("sent = %d, want 1", progress.Sent)
+	}
+	if m.count("wait:sendMessage") != 1 || m.count("retry:sendMessage") != 1 {
+		t.Errorf("counts = %v, want one wait and one retry", m.counts)
+	}
+}
+
+func TestMetricsQueueDepth(t *testing.T) {
+	bot, _ := newTestBot(t)
+	m := newCountingMetrics()
+	bot.UseMetrics(m)
+	bot.UseWorkerPool(tgx.WorkerPoolOptions{Workers: 1})
+
+	if status := deliver(bot, messageUpdate(1)); status != 200 {
+		t.Fatalf("status = %d", status)
+	}
+	if err := bot.StopWorkerPool(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// when queued and when handled
+	if m.count("queue_depth") != 2 {
+		t.Errorf("queue depth reported %d times, want 2", m.count("queue_depth"))
+	}
+}
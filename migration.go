@@ -0,0 +1,95 @@
+package tgx
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// OnChatMigrated sets the hook called when a group turns out to have been
+// upgraded to a supergroup, either from the service messages announcing it
+// or from a call to the old chat failing with ErrChatMigrated. Stored chat
+// ids should be updated from it. The hook may be called more than once for
+// the same migration.
+func (b *Bot) OnChatMigrated(hook func(oldChatID, newChatID int64)) {
+	b.chatMigratedHook = hook
+}
+
+// RetryMigratedChats makes calls failing with ErrChatMigrated be sent again
+// to the supergroup the chat was upgraded to. Calls uploading a file created
+// with FileFromReader can't be sent again, they fail with ErrChatMigrated.
+func (b *Bot) RetryMigratedChats(enabled bool) {
+	b.retryMigratedChats = enabled
+}
+
+func (b *Bot) chatMigrated(oldChatID, newChatID int64) {
+	b.logger.Info("Chat migrated to a supergroup", "chat_id", oldChatID, "new_chat_id", newChatID)
+	if b.chatMigratedHook != nil {
+		b.chatMigratedHook(oldChatID, newChatID)
+	}
+}
+
+// handleMigration reports the migration announced by a service message, it
+// returns false for other messages.
+func (b *Bot) handleMigration(message *models.Message) bool {
+	switch {
+	case message.MigrateToChatId != 0:
+		b.chatMigrated(message.Chat.Id, message.MigrateToChatId)
+	case message.MigrateFromChatId != 0:
+		b.chatMigrated(message.MigrateFromChatId, message.Chat.Id)
+	default:
+		return false
+	}
+	return true
+}
+
+// chatIDParam returns the numeric chat_id of a call, if it has one.
+func chatIDParam(params map[string]interface{}) (int64, bool) {
+	switch v := params["chat_id"].(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case json.Number:
+		// params of stored jobs are decoded as numbers
+		id, err := v.Int64()
+		return id, err == nil
+	case string:
+		// channel usernames can't migrate
+		id, err := strconv.ParseInt(v, 10, 64)
+		return id, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// replayable reports whether params can be sent again, uploads read from a
+// reader were consumed by the first attempt.
+func replayable(params map[string]interface{}) bool {
+	for _, val := range params {
+		switch v := val.(type) {
+		case *InputFile:
+			if !v.replayable() {
+				return false
+			}
+		case []InputMedia:
+			for _, media := range v {
+				if !media.Media.replayable() || !media.Thumbnail.replayable() {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// withChatID returns a copy of params sent to another chat.
+func withChatID(params map[string]interface{}, chatID int64) map[string]interface{} {
+	copied := make(map[string]interface{}, len(params))
+	for key, val := range params {
+		copied[key] = val
+	}
+	copied["chat_id"] = chatID
+	return copied
+}
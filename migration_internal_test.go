@@ -0,0 +1,28 @@
+package tgx
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChatIDParam(t *testing.T) {
+	tests := []struct {
+		chatID interface{}
+		want   int64
+		ok     bool
+	}{
+		{int64(-100123), -100123, true},
+		{-5, -5, true},
+		{json.Number("-100123"), -100123, true},
+		{json.Number("1.5"), 0, false},
+		{"-100123", -100123, true},
+		{"@channel", 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := chatIDParam(map[string]interface{}{"chat_id": tt.chatID})
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("chatIDParam(%#v) = %d, %v, want %d, %v", tt.chatID, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package tgx_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func migrated(newChatID int64) tgxtest.Response {
+	return tgxtest.Response{
+		ErrorCode:   400,
+		Description: "Bad Request: group chat was upgraded to a supergroup chat",
+		MigrateTo:   newChatID,
+	}
+}
+
+type migration struct{ from, to int64 }
+
+func recordMigrations(bot *tgx.Bot) *[]migration {
+	var migrations []migration
+	bot.OnChatMigrated(func(oldChatID, newChatID int64) {
+		migrations = append(migrations, migration{oldChatID, newChatID})
+	})
+	return &migrations
+}
+
+func TestChatMigratedServiceMessages(t *testing.T) {
+	bot, _ := newTestBot(t)
+	migrations := recordMigrations(bot)
+
+	bot.ProcessUpdate(&models.Update{UpdateId: 1, Message: &models.Message{
+		Chat:            models.Chat{Id: -1, Type: "group"},
+		MigrateToChatId: -1001,
+	}})
+	bot.ProcessUpdate(&models.Update{UpdateId: 2, Message: &models.Message{
+		Chat:              models.Chat{Id: -1001, Type: "supergroup"},
+		MigrateFromChatId: -1,
+	}})
+
+	want := []migration{{-1, -1001}, {-1, -1001}}
+	if len(*migrations) != 2 || (*migrations)[0] != want[0] || (*migrations)[1] != want[1] {
+		t.Errorf("migrations = %v, want %v", *migrations, want)
+	}
+}
+
+func TestChatMigratedWithoutRetry(t *testing.T) {
+	bot, srv := newTestBot(t)
+	migrations := recordMigrations(bot)
+	srv.Queue("sendMessage", migrated(-1001))
+
+	err := bot.SendMessage(-1, "hi")
+	if !errors.Is(err, tgx.ErrChatMigrated) {
+		t.Fatalf("err = %v, want ErrChatMigrated", err)
+	}
+	if id, ok := tgx.MigratedChatID(err); !ok || id != -1001 {
+		t.Errorf("MigratedChatID = %d, %v", id, ok)
+	}
+	if len(*migrations) != 1 || (*migrations)[0] != (migration{-1, -1001}) {
+		t.Errorf("migrations = %v", *migrations)
+	}
+	if n := len(srv.CallsTo("sendMessage")); n != 1 {
+		t.Errorf("%d calls, want 1", n)
+	}
+}
+
+func TestChatMigratedRetry(t *testing.T) {
+	bot, srv := newTestBot(t)
+	migrations := recordMigrations(bot)
+	bot.RetryMigratedChats(true)
+	srv.Queue("sendMessage", migrated(-1001))
+
+	if err := bot.SendMessage(-1, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	calls := srv.CallsTo("sendMessage")
+	if len(calls) != 2 || calls[0].Int("chat_id") != -1 || calls[1].Int("chat_id") != -1001 {
+		t.Fatalf("calls = %v", calls)
+	}
+	if calls[1].String("text") != "hi" {
+		t.Errorf("retried text = %q", calls[1].String("text"))
+	}
+	if len(*migrations) != 1 {
+		t.Errorf("migrations = %v", *migrations)
+	}
+}
+
+func TestChatMigratedRetryUploads(t *testing.T) {
+	bot, srv := newTestBot(t)
+	bot.RetryMigratedChats(true)
+	srv.Queue("sendDocument", migrated(-1001))
+
+	err := bot.SendDocumentFile(&tgx.SendDocumentRequest{
+		BaseMediaRequest: tgx.BaseMediaRequest{ChatId: -1},
+		Document:         tgx.FileFromBytes("report.txt", []byte("report")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := srv.CallsTo("sendDocument")
+	if len(calls) != 2 || calls[1].Int("chat_id") != -1001 || string(calls[1].Files["document"]) != "report" {
+		t.Errorf("calls = %v", calls)
+	}
+}
+
+// A reader can't be sent twice, the call fails instead of sending an empty
+// file to the supergroup.
+func TestChatMigratedNoRetryOfConsumedReader(t *testing.T) {
+	bot, srv := newTestBot(t)
+	migrations := recordMigrations(bot)
+	bot.RetryMigratedChats(true)
+	srv.Queue("sendDocument", migrated(-1001))
+
+	err := bot.SendDocumentFile(&tgx.SendDocumentRequest{
+		BaseMediaRequest: tgx.BaseMediaRequest{ChatId: -1},
+		Document:         tgx.FileFromReader("report.txt", bytes.NewReader([]byte("report"))),
+	})
+	if !errors.Is(err, tgx.ErrChatMigrated) {
+		t.Errorf("err = %v, want ErrChatMigrated", err)
+	}
+	if n := len(srv.CallsTo("sendDocument")); n != 1 {
+		t.Errorf("%d calls, want 1", n)
+	}
+	if len(*migrations) != 1 {
+		t.Errorf("the migration wasn't reported: %v", *migrations)
+	}
+}
+
+func TestChatMigratedNoRetryOfConsumedMedia(t *testing.T) {
+	bot, srv := newTestBot(t)
+	bot.RetryMigratedChats(true)
+	srv.Queue("sendMediaGroup", migrated(-1001))
+
+	err := bot.SendMediaGroup(-1, []tgx.InputMedia{
+		{Type: "photo", Media: tgx.FileFromID("photo-id")},
+		{Type: "photo", Media: tgx.FileFromReader("b.jpg", bytes.NewReader([]byte("b")))},
+	})
+	if !errors.Is(err, tgx.ErrChatMigrated) {
+		t.Errorf("err = %v, want ErrChatMigrated", err)
+	}
+	if n := len(srv.CallsTo("sendMediaGroup")); n != 1 {
+		t.Errorf("%d calls, want 1", n)
+	}
+}
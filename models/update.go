@@ -24,19 +24,61 @@ type CallbackQuery struct {
 }
 
 type Message struct {
-	MessageId      int64                 `json:"message_id"`
-	From           User                  `json:"from"`
-	Chat           Chat                  `json:"chat"`
-	Text           string                `json:"text"`
-	ReplyToMessage *Message              `json:"reply_to_message"`
-	ReplyMarkup    *InlineKeyboardMarkup `json:"reply_markup"`
-	Animation      *Animation            `json:"animation"`
-	Audio          *Audio                `json:"audio"`
-	Document       *Document             `json:"document"`
-	Photo          []*PhotoSize          `json:"photo"`
-	Sticker        *Sticker              `json:"sticker"`
-	Video          *Video                `json:"video"`
-	VideoNote      *VideoNote            `json:"video_note"`
-	Voice          *Voice                `json:"voice"`
-	Caption        string                `json:"caption"`
+	MessageId       int64                 `json:"message_id"`
+	From            User                  `json:"from"`
+	Chat            Chat                  `json:"chat"`
+	Text            string                `json:"text"`
+	ReplyToMessage  *Message              `json:"reply_to_message"`
+	ReplyMarkup     *InlineKeyboardMarkup `json:"reply_markup"`
+	Animation       *Animation            `json:"animation"`
+	Audio           *Audio                `json:"audio"`
+	Document        *Document             `json:"document"`
+	Photo           []*PhotoSize          `json:"photo"`
+	Sticker         *Sticker              `json:"sticker"`
+	Video           *Video                `json:"video"`
+	VideoNote       *VideoNote            `json:"video_note"`
+	Voice           *Voice                `json:"voice"`
+	Caption         string                `json:"caption"`
+	Entities        []MessageEntity       `json:"entities"`
+	CaptionEntities []MessageEntity       `json:"caption_entities"`
+
+	// set on the service messages sent when a group is upgraded to a
+	// supergroup, in the old and the new chat respectively
+	MigrateToChatId   int64 `json:"migrate_to_chat_id,omitempty"`
+	MigrateFromChatId int64 `json:"migrate_from_chat_id,omitempty"`
+}
+
+// MessageEntity represents one special entity in a text message. Offset and
+// Length are measured in UTF-16 code units.
+type MessageEntity struct {
+	Type          string `json:"type"`
+	Offset        int    `json:"offset"`
+	Length        int    `json:"length"`
+	URL           string `json:"url,omitempty"`             // text_link only
+	User          *User  `json:"user,omitempty"`            // text_mention only
+	Language      string `json:"language,omitempty"`        // pre only
+	CustomEmojiId string `json:"custom_emoji_id,omitempty"` // custom_emoji only
 }
+
+// Message entity types
+const (
+	EntityMention              = "mention"
+	EntityHashtag              = "hashtag"
+	EntityCashtag              = "cashtag"
+	EntityBotCommand           = "bot_command"
+	EntityURL                  = "url"
+	EntityEmail                = "email"
+	EntityPhoneNumber          = "phone_number"
+	EntityBold                 = "bold"
+	EntityItalic               = "italic"
+	EntityUnderline            = "underline"
+	EntityStrikethrough        = "strikethrough"
+	EntitySpoiler              = "spoiler"
+	EntityBlockquote           = "blockquote"
+	EntityExpandableBlockquote = "expandable_blockquote"
+	EntityCode                 = "code"
+	EntityPre                  = "pre"
+	EntityTextLink             = "text_link"
+	EntityTextMention          = "text_mention"
+	EntityCustomEmoji          = "custom_emoji"
+)
@@ -0,0 +1,50 @@
+package tgx
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// PanicError is a panic recovered while handling an update. Handlers that
+// panic return it, and the error handler receives it.
+type PanicError struct {
+	Value interface{}
+	Stack []byte // stack of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// OnPanic sets the hook called with every panic recovered while handling an
+// update, e.g. to report it to an error tracker. Panics are logged with
+// their stack either way. The update is nil for panics in scheduled jobs.
+func (b *Bot) OnPanic(hook func(update *models.Update, err *PanicError)) {
+	b.panicHook = hook
+}
+
+// recovered turns a recovered panic value into a *PanicError and reports
+// it. It must be called from the deferred function that recovered, so the
+// stack still shows where the panic happened.
+func (b *Bot) recovered(update *models.Update, r interface{}) *PanicError {
+	err := &PanicError{Value: r, Stack: debug.Stack()}
+
+	updateID := 0
+	if update != nil {
+		updateID = update.UpdateId
+	}
+	b.logger.Error("Panic recovered", "update_id", updateID, "panic", r, "stack", string(err.Stack))
+
+	if b.panicHook != nil {
+		b.panicHook(update, err)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package tgx_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+)
+
+func callbackUpdate(id int, data string) *models.Update {
+	return &models.Update{
+		UpdateId: id,
+		CallbackQuery: &models.CallbackQuery{
+			ID:   "cb",
+			From: models.User{Id: 42},
+			Data: data,
+			Message: &models.Message{
+				MessageId: 1,
+				Chat:      models.Chat{Id: 42, Type: "private"},
+			},
+		},
+	}
+}
+
+type recordedPanic struct {
+	update *models.Update
+	err    *tgx.PanicError
+}
+
+func recordPanics(bot *tgx.Bot) *[]recordedPanic {
+	var panics []recordedPanic
+	bot.OnPanic(func(update *models.Update, err *tgx.PanicError) {
+		panics = append(panics, recordedPanic{update, err})
+	})
+	return &panics
+}
+
+func TestPanicInCommandHandler(t *testing.T) {
+	bot, srv := newTestBot(t)
+	panics := recordPanics(bot)
+	var handled error
+	bot.OnUpdateError(func(ctx *tgx.ErrorContext, err error) {
+		handled = err
+	})
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		panic("boom")
+	})
+
+	update := commandUpdate(3, "/start")
+	bot.ProcessUpdate(update)
+
+	if len(*panics) != 1 {
+		t.Fatalf("got %d panics", len(*panics))
+	}
+	p := (*panics)[0]
+	if p.update != update || p.err.Value != "boom" {
+		t.Errorf("panic = %+v", p)
+	}
+	// the stack shows where the panic happened
+	if !strings.Contains(string(p.err.Stack), "TestPanicInCommandHandler") {
+		t.Errorf("stack doesn't contain the handler:\n%s", p.err.Stack)
+	}
+	var panicErr *tgx.PanicError
+	if !errors.As(handled, &panicErr) || panicErr != p.err {
+		t.Errorf("error handler got %v, want the PanicError", handled)
+	}
+	if len(srv.Calls()) != 0 {
+		t.Errorf("unexpected calls: %v", srv.Calls())
+	}
+}
+
+func TestPanicInCallbackHandler(t *testing.T) {
+	bot, srv := newTestBot(t)
+	panics := recordPanics(bot)
+	bot.OnCallback("settings", func(ctx *tgx.CallbackContext) error {
+		panic(io.ErrUnexpectedEOF)
+	})
+
+	bot.ProcessUpdate(callbackUpdate(4, "settings"))
+
+	if len(*panics) != 1 || (*panics)[0].update.UpdateId != 4 {
+		t.Fatalf("panics = %v", *panics)
+	}
+	// error values are unwrapped
+	if err := (*panics)[0].err; !errors.Is(err, io.ErrUnexpectedEOF) || err.Error() != "handler panic: unexpected EOF" {
+		t.Errorf("err = %v", err)
+	}
+	// the default error handler answers the callback query
+	if calls := srv.CallsTo("answerCallbackQuery"); len(calls) != 1 {
+		t.Errorf("got %d answers", len(calls))
+	}
+}
+
+// Panics in hooks run while dispatching the update are recovered too.
+func TestPanicOutsideHandlers(t *testing.T) {
+	bot, _ := newTestBot(t)
+	panics := recordPanics(bot)
+	bot.OnChatMigrated(func(oldChatID, newChatID int64) {
+		panic("hook failed")
+	})
+
+	bot.ProcessUpdate(&models.Update{UpdateId: 5, Message: &models.Message{
+		Chat:            models.Chat{Id: -1, Type: "group"},
+		MigrateToChatId: -1001,
+	}})
+
+	if len(*panics) != 1 || (*panics)[0].update.UpdateId != 5 || (*panics)[0].err.Value != "hook failed" {
+		t.Errorf("panics = %v", *panics)
+	}
+}
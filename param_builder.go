@@ -1,9 +1,6 @@
 package tgx
 
-import (
-	"log"
-	"sync"
-)
+import "sync"
 
 type ParamBuilder struct {
 	params map[string]interface{}
@@ -15,12 +12,10 @@ func NewParamBuilder() *ParamBuilder {
 }
 
 func (pb *ParamBuilder) Add(key string, value interface{}) *ParamBuilder {
-	log.Printf("Adding key: %s with value: %v", key, value)
 	pb.mu.Lock()
 	defer pb.mu.Unlock()
 
 	if value == nil {
-		log.Printf("Skipping key: %s because value is nil", key)
 		return pb
 	}
 
@@ -28,14 +23,10 @@ func (pb *ParamBuilder) Add(key string, value interface{}) *ParamBuilder {
 	case string:
 		if v != "" {
 			pb.params[key] = v
-		} else {
-			log.Printf("Skipping key: %s because value is an empty string", key)
 		}
 	case *string:
 		if v != nil && *v != "" {
 			pb.params[key] = *v
-		} else {
-			log.Printf("Skipping key: %s because value is nil or points to an empty string", key)
 		}
 	case int, int64, float64, bool:
 		pb.params[key] = v
@@ -55,9 +46,12 @@ func (pb *ParamBuilder) Add(key string, value interface{}) *ParamBuilder {
 		if v != nil {
 			pb.params[key] = *v
 		}
+	case *InputFile:
+		if v != nil {
+			pb.params[key] = v
+		}
 	default:
-		// pb.params[key] = value
-		log.Printf("Unsupported type for key: %s, skipping", key)
+		// unsupported types are skipped
 	}
 	return pb
 }
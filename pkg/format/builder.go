@@ -0,0 +1,168 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+type segment struct {
+	kind    string // entity type, empty for plain text
+	text    string
+	url     string
+	lang    string
+	userID  int64
+	emojiID string
+}
+
+// Builder assembles a formatted message from plain and styled pieces. Text
+// passed to the builder is raw and gets escaped when rendered, so it is safe
+// to use with user-provided input.
+type Builder struct {
+	segments []segment
+}
+
+func New() *Builder {
+	return &Builder{}
+}
+
+func (b *Builder) add(s segment) *Builder {
+	if s.text != "" {
+		b.segments = append(b.segments, s)
+	}
+	return b
+}
+
+func (b *Builder) Text(text string) *Builder {
+	return b.add(segment{text: text})
+}
+
+func (b *Builder) Textf(format string, args ...any) *Builder {
+	return b.Text(fmt.Sprintf(format, args...))
+}
+
+func (b *Builder) Line(text string) *Builder {
+	return b.Text(text + "\n")
+}
+
+func (b *Builder) Bold(text string) *Builder {
+	return b.add(segment{kind: models.EntityBold, text: text})
+}
+
+func (b *Builder) Italic(text string) *Builder {
+	return b.add(segment{kind: models.EntityItalic, text: text})
+}
+
+func (b *Builder) Underline(text string) *Builder {
+	return b.add(segment{kind: models.EntityUnderline, text: text})
+}
+
+func (b *Builder) Strikethrough(text string) *Builder {
+	return b.add(segment{kind: models.EntityStrikethrough, text: text})
+}
+
+func (b *Builder) Spoiler(text string) *Builder {
+	return b.add(segment{kind: models.EntitySpoiler, text: text})
+}
+
+func (b *Builder) Code(text string) *Builder {
+	return b.add(segment{kind: models.EntityCode, text: text})
+}
+
+// Pre adds a pre-formatted block, lang may be empty.
+func (b *Builder) Pre(lang, code string) *Builder {
+	return b.add(segment{kind: models.EntityPre, text: code, lang: lang})
+}
+
+func (b *Builder) Link(text, url string) *Builder {
+	return b.add(segment{kind: models.EntityTextLink, text: text, url: url})
+}
+
+// Mention links text to a user by id, for users without a username.
+func (b *Builder) Mention(text string, userID int64) *Builder {
+	return b.add(segment{kind: models.EntityTextMention, text: text, userID: userID})
+}
+
+// Blockquote adds a quote on lines of its own, newlines are added around it
+// when missing since MarkdownV2 quotes must start a line and following text
+// would be quoted too.
+func (b *Builder) Blockquote(text string) *Builder {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return b
+	}
+	if n := len(b.segments); n > 0 && !strings.HasSuffix(b.segments[n-1].text, "\n") {
+		b.Text("\n")
+	}
+	b.add(segment{kind: models.EntityBlockquote, text: text})
+	return b.Text("\n")
+}
+
+// CustomEmoji adds a custom emoji, emoji is the fallback shown by clients
+// that can't render it.
+func (b *Builder) CustomEmoji(emoji, emojiID string) *Builder {
+	return b.add(segment{kind: models.EntityCustomEmoji, text: emoji, emojiID: emojiID})
+}
+
+// Render returns the message formatted for the given parse mode. Any other
+// mode returns plain text.
+func (b *Builder) Render(mode string) string {
+	switch mode {
+	case ModeHTML:
+		return b.HTML()
+	case ModeMarkdownV2:
+		return b.MarkdownV2()
+	default:
+		return b.String()
+	}
+}
+
+// String returns the message as plain text without any formatting.
+func (b *Builder) String() string {
+	var sb strings.Builder
+	for _, s := range b.segments {
+		sb.WriteString(s.text)
+	}
+	return sb.String()
+}
+
+func (b *Builder) HTML() string {
+	return EntitiesToHTML(b.Entities())
+}
+
+func (b *Builder) MarkdownV2() string {
+	return EntitiesToMarkdownV2(b.Entities())
+}
+
+// Entities returns the message as plain text together with the entities
+// describing its formatting, for use without a parse mode.
+func (b *Builder) Entities() (string, []models.MessageEntity) {
+	var sb strings.Builder
+	var entities []models.MessageEntity
+	offset := 0
+	for _, s := range b.segments {
+		length := UTF16Len(s.text)
+		if s.kind != "" {
+			entity := models.MessageEntity{
+				Type:   s.kind,
+				Offset: offset,
+				Length: length,
+			}
+			switch s.kind {
+			case models.EntityPre:
+				entity.Language = s.lang
+			case models.EntityTextLink:
+				entity.URL = s.url
+			case models.EntityTextMention:
+				entity.User = &models.User{Id: s.userID}
+			case models.EntityCustomEmoji:
+				entity.CustomEmojiId = s.emojiID
+			}
+			entities = append(entities, entity)
+		}
+		sb.WriteString(s.text)
+		offset += length
+	}
+	return sb.String(), entities
+}
@@ -0,0 +1,59 @@
+package format
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+func TestBuilderRender(t *testing.T) {
+	b := New().
+		Text("Hi ").Bold("Bob_1").Text("! ").
+		Italic("a*b").Text(" ").
+		Code("x<y").Text(" ").
+		Link("docs", "https://example.com/a_(b)")
+
+	if got, want := b.String(), "Hi Bob_1! a*b x<y docs"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := b.HTML(), `Hi <b>Bob_1</b>! <i>a*b</i> <code>x&lt;y</code> <a href="https://example.com/a_(b)">docs</a>`; got != want {
+		t.Errorf("HTML() = %q, want %q", got, want)
+	}
+	if got, want := b.MarkdownV2(), `Hi *Bob\_1*\! _a\*b_ `+"`x<y`"+` [docs](https://example.com/a_(b\))`; got != want {
+		t.Errorf("MarkdownV2() = %q, want %q", got, want)
+	}
+	if got := b.Render("unknown"); got != b.String() {
+		t.Errorf("Render(unknown) = %q", got)
+	}
+}
+
+func TestBuilderEntities(t *testing.T) {
+	text, entities := New().
+		Text("👋 ").
+		Bold("hi").
+		Text(" ").
+		Pre("go", "x := 1").
+		Mention("Ann", 42).
+		Entities()
+
+	if text != "👋 hi x := 1Ann" {
+		t.Fatalf("text = %q", text)
+	}
+	want := []models.MessageEntity{
+		// the emoji is two UTF-16 code units
+		{Type: models.EntityBold, Offset: 3, Length: 2},
+		{Type: models.EntityPre, Offset: 6, Length: 6, Language: "go"},
+		{Type: models.EntityTextMention, Offset: 12, Length: 3, User: &models.User{Id: 42}},
+	}
+	if !reflect.DeepEqual(entities, want) {
+		t.Errorf("entities = %+v, want %+v", entities, want)
+	}
+}
+
+func TestBuilderSkipsEmptySegments(t *testing.T) {
+	_, entities := New().Text("a").Bold("").Italic("").Entities()
+	if len(entities) != 0 {
+		t.Errorf("entities = %+v, want none", entities)
+	}
+}
@@ -0,0 +1,346 @@
+package format
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// EntityText returns the part of text covered by entity.
+func EntityText(text string, entity models.MessageEntity) string {
+	return UTF16Slice(text, entity.Offset, entity.Length)
+}
+
+// ExtractEntities returns the text of every entity of the given types, in
+// message order. With no types all entities are returned.
+func ExtractEntities(text string, entities []models.MessageEntity, types ...string) []string {
+	units := utf16.Encode([]rune(text))
+	var values []string
+	for _, e := range entities {
+		if len(types) > 0 && !containsType(types, e.Type) {
+			continue
+		}
+		start, end := clamp(e.Offset, len(units)), clamp(e.Offset+e.Length, len(units))
+		if start < end {
+			values = append(values, string(utf16.Decode(units[start:end])))
+		}
+	}
+	return values
+}
+
+func containsType(types []string, t string) bool {
+	for _, typ := range types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// EntitiesToHTML renders text with its entities using the HTML parse mode,
+// for example to resend formatted text received from a user.
+func EntitiesToHTML(text string, entities []models.MessageEntity) string {
+	return renderEntities(text, entities, htmlMarkup{})
+}
+
+// EntitiesToMarkdownV2 renders text with its entities using the MarkdownV2
+// parse mode.
+func EntitiesToMarkdownV2(text string, entities []models.MessageEntity) string {
+	return renderEntities(text, entities, &markdownV2Markup{})
+}
+
+// EntitiesTo renders text with its entities for the given parse mode. Any
+// other mode returns the text unchanged.
+func EntitiesTo(mode, text string, entities []models.MessageEntity) string {
+	switch mode {
+	case ModeHTML:
+		return EntitiesToHTML(text, entities)
+	case ModeMarkdownV2:
+		return EntitiesToMarkdownV2(text, entities)
+	default:
+		return text
+	}
+}
+
+type markup interface {
+	open(e models.MessageEntity) string
+	close(e models.MessageEntity) string
+	// text escapes plain text, stack holds the entities enclosing it
+	text(s string, stack []models.MessageEntity) string
+}
+
+func renderEntities(text string, entities []models.MessageEntity, m markup) string {
+	units := utf16.Encode([]rune(text))
+
+	sorted := make([]models.MessageEntity, 0, len(entities))
+	for _, e := range entities {
+		if e.Length > 0 && e.Offset >= 0 && e.Offset < len(units) {
+			sorted = append(sorted, e)
+		}
+	}
+	// outer entities first so they are opened before the ones they contain
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Offset != sorted[j].Offset {
+			return sorted[i].Offset < sorted[j].Offset
+		}
+		return sorted[i].Length > sorted[j].Length
+	})
+
+	var sb strings.Builder
+	var stack []models.MessageEntity
+	next, pos := 0, 0
+	for pos <= len(units) {
+		// close entities ending here; an entity overlapping the ones opened
+		// after it forces those to be closed and reopened
+		for i := len(stack) - 1; i >= 0; i-- {
+			if end(stack[i]) > pos && pos < len(units) {
+				continue
+			}
+			reopen := append([]models.MessageEntity(nil), stack[i+1:]...)
+			for j := len(stack) - 1; j >= i; j-- {
+				sb.WriteString(m.close(stack[j]))
+			}
+			stack = stack[:i]
+			for _, e := range reopen {
+				if end(e) > pos && pos < len(units) {
+					sb.WriteString(m.open(e))
+					stack = append(stack, e)
+				}
+			}
+			i = len(stack)
+		}
+		if pos == len(units) {
+			break
+		}
+
+		for next < len(sorted) && sorted[next].Offset == pos {
+			sb.WriteString(m.open(sorted[next]))
+			stack = append(stack, sorted[next])
+			next++
+		}
+
+		// write plain text up to the next entity boundary
+		stop := len(units)
+		if next < len(sorted) && sorted[next].Offset < stop {
+			stop = sorted[next].Offset
+		}
+		for _, e := range stack {
+			if end(e) < stop {
+				stop = end(e)
+			}
+		}
+		sb.WriteString(m.text(string(utf16.Decode(units[pos:stop])), stack))
+		pos = stop
+	}
+	return sb.String()
+}
+
+func end(e models.MessageEntity) int {
+	return e.Offset + e.Length
+}
+
+func inCode(stack []models.MessageEntity) bool {
+	for _, e := range stack {
+		if e.Type == models.EntityCode || e.Type == models.EntityPre {
+			return true
+		}
+	}
+	return false
+}
+
+func inBlockquote(stack []models.MessageEntity) bool {
+	for _, e := range stack {
+		if e.Type == models.EntityBlockquote || e.Type == models.EntityExpandableBlockquote {
+			return true
+		}
+	}
+	return false
+}
+
+func userID(e models.MessageEntity) int64 {
+	if e.User == nil {
+		return 0
+	}
+	return e.User.Id
+}
+
+type htmlMarkup struct{}
+
+func (htmlMarkup) open(e models.MessageEntity) string {
+	switch e.Type {
+	case models.EntityBold:
+		return "<b>"
+	case models.EntityItalic:
+		return "<i>"
+	case models.EntityUnderline:
+		return "<u>"
+	case models.EntityStrikethrough:
+		return "<s>"
+	case models.EntitySpoiler:
+		return "<tg-spoiler>"
+	case models.EntityCode:
+		return "<code>"
+	case models.EntityPre:
+		if e.Language != "" {
+			return `<pre><code class="language-` + EscapeHTML(e.Language) + `">`
+		}
+		return "<pre>"
+	case models.EntityTextLink:
+		return `<a href="` + EscapeHTML(e.URL) + `">`
+	case models.EntityTextMention:
+		return fmt.Sprintf(`<a href="tg://user?id=%d">`, userID(e))
+	case models.EntityBlockquote:
+		return "<blockquote>"
+	case models.EntityExpandableBlockquote:
+		return "<blockquote expandable>"
+	case models.EntityCustomEmoji:
+		return `<tg-emoji emoji-id="` + EscapeHTML(e.CustomEmojiId) + `">`
+	default:
+		return ""
+	}
+}
+
+func (htmlMarkup) close(e models.MessageEntity) string {
+	switch e.Type {
+	case models.EntityBold:
+		return "</b>"
+	case models.EntityItalic:
+		return "</i>"
+	case models.EntityUnderline:
+		return "</u>"
+	case models.EntityStrikethrough:
+		return "</s>"
+	case models.EntitySpoiler:
+		return "</tg-spoiler>"
+	case models.EntityCode:
+		return "</code>"
+	case models.EntityPre:
+		if e.Language != "" {
+			return "</code></pre>"
+		}
+		return "</pre>"
+	case models.EntityTextLink, models.EntityTextMention:
+		return "</a>"
+	case models.EntityBlockquote, models.EntityExpandableBlockquote:
+		return "</blockquote>"
+	case models.EntityCustomEmoji:
+		return "</tg-emoji>"
+	default:
+		return ""
+	}
+}
+
+func (htmlMarkup) text(s string, _ []models.MessageEntity) string {
+	return EscapeHTML(s)
+}
+
+type markdownV2Markup struct {
+	// set when the last write ended with an "_" marker
+	underscore bool
+	// set when the last write didn't end a line
+	midLine bool
+}
+
+func (m *markdownV2Markup) marker(s string) string {
+	// "__" is always read as underline, so adjacent italic and underline
+	// markers are split the way the Bot API documentation suggests
+	if m.underscore && strings.HasPrefix(s, "_") {
+		s = "\r" + s
+	}
+	m.underscore = strings.HasSuffix(s, "_")
+	m.wrote(s)
+	return s
+}
+
+func (m *markdownV2Markup) wrote(s string) {
+	if s != "" {
+		m.midLine = !strings.HasSuffix(s, "\n")
+	}
+}
+
+// quote opens a blockquote, which MarkdownV2 only recognizes at the start of
+// a line. Quotes starting mid-line are moved to a line of their own.
+func (m *markdownV2Markup) quote(marker string) string {
+	if m.midLine {
+		marker = "\n" + marker
+	}
+	return m.marker(marker)
+}
+
+func (m *markdownV2Markup) open(e models.MessageEntity) string {
+	switch e.Type {
+	case models.EntityBold:
+		return m.marker("*")
+	case models.EntityItalic:
+		return m.marker("_")
+	case models.EntityUnderline:
+		return m.marker("__")
+	case models.EntityStrikethrough:
+		return m.marker("~")
+	case models.EntitySpoiler:
+		return m.marker("||")
+	case models.EntityCode:
+		return m.marker("`")
+	case models.EntityPre:
+		return m.marker("```" + e.Language + "\n")
+	case models.EntityTextLink, models.EntityTextMention:
+		return m.marker("[")
+	case models.EntityBlockquote:
+		return m.quote(">")
+	case models.EntityExpandableBlockquote:
+		return m.quote("**>")
+	case models.EntityCustomEmoji:
+		return m.marker("![")
+	default:
+		return ""
+	}
+}
+
+func (m *markdownV2Markup) close(e models.MessageEntity) string {
+	switch e.Type {
+	case models.EntityBold:
+		return m.marker("*")
+	case models.EntityItalic:
+		return m.marker("_")
+	case models.EntityUnderline:
+		return m.marker("__")
+	case models.EntityStrikethrough:
+		return m.marker("~")
+	case models.EntitySpoiler:
+		return m.marker("||")
+	case models.EntityCode:
+		return m.marker("`")
+	case models.EntityPre:
+		return m.marker("\n```")
+	case models.EntityTextLink:
+		return m.marker("](" + EscapeMarkdownV2URL(e.URL) + ")")
+	case models.EntityTextMention:
+		return m.marker(fmt.Sprintf("](tg://user?id=%d)", userID(e)))
+	case models.EntityExpandableBlockquote:
+		return m.marker("||")
+	case models.EntityCustomEmoji:
+		return m.marker("](tg://emoji?id=" + EscapeMarkdownV2URL(e.CustomEmojiId) + ")")
+	default:
+		return ""
+	}
+}
+
+func (m *markdownV2Markup) text(s string, stack []models.MessageEntity) string {
+	if s == "" {
+		return ""
+	}
+	m.underscore = false
+	if inCode(stack) {
+		s = EscapeMarkdownV2Code(s)
+	} else {
+		s = EscapeMarkdownV2(s)
+		if inBlockquote(stack) {
+			s = strings.ReplaceAll(s, "\n", "\n>")
+		}
+	}
+	m.wrote(s)
+	return s
+}
@@ -0,0 +1,112 @@
+package format
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+func TestExtractEntities(t *testing.T) {
+	text := "👋 /start @bob #go https://go.dev"
+	entities := []models.MessageEntity{
+		{Type: models.EntityBotCommand, Offset: 3, Length: 6},
+		{Type: models.EntityMention, Offset: 10, Length: 4},
+		{Type: models.EntityHashtag, Offset: 15, Length: 3},
+		{Type: models.EntityURL, Offset: 19, Length: 14},
+	}
+
+	if got, want := ExtractEntities(text, entities), []string{"/start", "@bob", "#go", "https://go.dev"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("all = %q, want %q", got, want)
+	}
+	if got, want := ExtractEntities(text, entities, models.EntityMention, models.EntityURL), []string{"@bob", "https://go.dev"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filtered = %q, want %q", got, want)
+	}
+	if got := EntityText(text, entities[0]); got != "/start" {
+		t.Errorf("EntityText = %q", got)
+	}
+}
+
+func TestEntitiesToHTML(t *testing.T) {
+	text := "bold italic & <code>"
+	entities := []models.MessageEntity{
+		{Type: models.EntityBold, Offset: 0, Length: 11},
+		{Type: models.EntityItalic, Offset: 5, Length: 6},
+		{Type: models.EntityCode, Offset: 14, Length: 6},
+	}
+	want := "<b>bold <i>italic</i></b> &amp; <code>&lt;code&gt;</code>"
+	if got := EntitiesToHTML(text, entities); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEntitiesToMarkdownV2(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		entities []models.MessageEntity
+		want     string
+	}{
+		{
+			name:     "escaping",
+			text:     "1.5*2",
+			entities: []models.MessageEntity{{Type: models.EntityBold, Offset: 0, Length: 3}},
+			want:     `*1\.5*\*2`,
+		},
+		{
+			name: "adjacent italic and underline",
+			text: "ab",
+			entities: []models.MessageEntity{
+				{Type: models.EntityItalic, Offset: 0, Length: 1},
+				{Type: models.EntityUnderline, Offset: 1, Length: 1},
+			},
+			want: "_a_\r__b__",
+		},
+		{
+			name:     "pre keeps markdown characters",
+			text:     "a_b*c`",
+			entities: []models.MessageEntity{{Type: models.EntityPre, Offset: 0, Length: 6, Language: "go"}},
+			want:     "```go\na_b*c\\`\n```",
+		},
+		{
+			name:     "multi-line quote",
+			text:     "q1\nq2",
+			entities: []models.MessageEntity{{Type: models.EntityBlockquote, Offset: 0, Length: 5}},
+			want:     ">q1\n>q2",
+		},
+		{
+			name:     "quote starting mid-line",
+			text:     "see q",
+			entities: []models.MessageEntity{{Type: models.EntityBlockquote, Offset: 4, Length: 1}},
+			want:     "see \n>q",
+		},
+	}
+	for _, tt := range tests {
+		if got := EntitiesToMarkdownV2(tt.text, tt.entities); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Overlapping entities are closed and reopened so the output stays well
+// formed.
+func TestOverlappingEntities(t *testing.T) {
+	text := "abcdef"
+	entities := []models.MessageEntity{
+		{Type: models.EntityBold, Offset: 0, Length: 4},
+		{Type: models.EntityItalic, Offset: 2, Length: 4},
+	}
+	if got, want := EntitiesToHTML(text, entities), "<b>ab<i>cd</i></b><i>ef</i>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuilderBlockquoteAfterText(t *testing.T) {
+	b := New().Text("see ").Blockquote("q")
+	if got, want := b.MarkdownV2(), "see \n>q\n"; got != want {
+		t.Errorf("MarkdownV2() = %q, want %q", got, want)
+	}
+	if got, want := b.HTML(), "see \n<blockquote>q</blockquote>\n"; got != want {
+		t.Errorf("HTML() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+// Package format provides escaping helpers and a message builder for
+// Telegram's MarkdownV2 and HTML parse modes.
+package format
+
+import "strings"
+
+const (
+	ModeHTML       = "HTML"
+	ModeMarkdownV2 = "MarkdownV2"
+)
+
+var markdownV2Replacer = strings.NewReplacer(
+	`\`, `\\`,
+	"_", `\_`,
+	"*", `\*`,
+	"[", `\[`,
+	"]", `\]`,
+	"(", `\(`,
+	")", `\)`,
+	"~", `\~`,
+	"`", "\\`",
+	">", `\>`,
+	"#", `\#`,
+	"+", `\+`,
+	"-", `\-`,
+	"=", `\=`,
+	"|", `\|`,
+	"{", `\{`,
+	"}", `\}`,
+	".", `\.`,
+	"!", `\!`,
+)
+
+// inside pre and code entities only ` and \ must be escaped
+var markdownV2CodeReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+)
+
+// inside the (...) part of inline links only ) and \ must be escaped
+var markdownV2URLReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	")", `\)`,
+)
+
+var htmlReplacer = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+// EscapeMarkdownV2 escapes every character that has a special meaning in
+// MarkdownV2 so text can be sent verbatim.
+func EscapeMarkdownV2(text string) string {
+	return markdownV2Replacer.Replace(text)
+}
+
+// EscapeMarkdownV2Code escapes text placed inside a code or pre entity.
+func EscapeMarkdownV2Code(text string) string {
+	return markdownV2CodeReplacer.Replace(text)
+}
+
+// EscapeMarkdownV2URL escapes a URL placed inside an inline link.
+func EscapeMarkdownV2URL(url string) string {
+	return markdownV2URLReplacer.Replace(url)
+}
+
+// EscapeHTML escapes text for the HTML parse mode.
+func EscapeHTML(text string) string {
+	return htmlReplacer.Replace(text)
+}
+
+// Escape escapes text for the given parse mode. Unknown modes return the
+// text unchanged.
+func Escape(mode, text string) string {
+	switch mode {
+	case ModeMarkdownV2:
+		return EscapeMarkdownV2(text)
+	case ModeHTML:
+		return EscapeHTML(text)
+	default:
+		return text
+	}
+}
@@ -0,0 +1,61 @@
+package format
+
+import "testing"
+
+func TestEscapeMarkdownV2(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"1.5 + 2 = 3.5!", `1\.5 \+ 2 \= 3\.5\!`},
+		{"_*[]()~`>#+-=|{}.!", "\\_\\*\\[\\]\\(\\)\\~\\`\\>\\#\\+\\-\\=\\|\\{\\}\\.\\!"},
+		{`C:\path`, `C:\\path`},
+		{"привет 👋", "привет 👋"},
+	}
+	for _, tt := range tests {
+		if got := EscapeMarkdownV2(tt.in); got != tt.want {
+			t.Errorf("EscapeMarkdownV2(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeMarkdownV2CodeAndURL(t *testing.T) {
+	if got, want := EscapeMarkdownV2Code("a`b\\c*d"), "a\\`b\\\\c*d"; got != want {
+		t.Errorf("EscapeMarkdownV2Code = %q, want %q", got, want)
+	}
+	if got, want := EscapeMarkdownV2URL(`https://x.org/a_(b)\c`), `https://x.org/a_(b\)\\c`; got != want {
+		t.Errorf("EscapeMarkdownV2URL = %q, want %q", got, want)
+	}
+}
+
+func TestEscapeHTML(t *testing.T) {
+	if got, want := EscapeHTML(`<b>"Tom" & 'Jerry'</b>`), `&lt;b&gt;&quot;Tom&quot; &amp; 'Jerry'&lt;/b&gt;`; got != want {
+		t.Errorf("EscapeHTML = %q, want %q", got, want)
+	}
+}
+
+func TestEscapeMode(t *testing.T) {
+	if got := Escape(ModeHTML, "a<b"); got != "a&lt;b" {
+		t.Errorf("Escape(HTML) = %q", got)
+	}
+	if got := Escape(ModeMarkdownV2, "a.b"); got != `a\.b` {
+		t.Errorf("Escape(MarkdownV2) = %q", got)
+	}
+	if got := Escape("", "a.b<"); got != "a.b<" {
+		t.Errorf("Escape(none) = %q", got)
+	}
+}
+
+// Escaped text parses back to the original text without any entities.
+func TestEscapeRoundTrip(t *testing.T) {
+	text := "Price: $5.00 (50% off!) *limited* _time_ <b>only</b> & more [link](x)"
+	for _, mode := range []string{ModeHTML, ModeMarkdownV2} {
+		plain, entities, err := Parse(mode, Escape(mode, text))
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		if plain != text || len(entities) != 0 {
+			t.Errorf("%s: got %q %v, want %q without entities", mode, plain, entities, text)
+		}
+	}
+}
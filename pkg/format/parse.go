@@ -0,0 +1,406 @@
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// Parse converts text formatted for the given parse mode into plain text and
+// entities. Text with any other mode is returned unchanged.
+func Parse(mode, text string) (string, []models.MessageEntity, error) {
+	switch mode {
+	case ModeHTML:
+		return ParseHTML(text)
+	case ModeMarkdownV2:
+		return ParseMarkdownV2(text)
+	default:
+		return text, nil, nil
+	}
+}
+
+// entityWriter accumulates plain text and tracks the UTF-16 offset of its end.
+type entityWriter struct {
+	sb       strings.Builder
+	offset   int
+	entities []models.MessageEntity
+}
+
+func (w *entityWriter) write(s string) {
+	w.sb.WriteString(s)
+	w.offset += UTF16Len(s)
+}
+
+// add records an entity spanning from start to the current offset.
+func (w *entityWriter) add(e models.MessageEntity, start int) {
+	w.addRange(e, start, w.offset)
+}
+
+func (w *entityWriter) addRange(e models.MessageEntity, start, end int) {
+	e.Offset = start
+	e.Length = end - start
+	if e.Length > 0 {
+		w.entities = append(w.entities, e)
+	}
+}
+
+func (w *entityWriter) result() (string, []models.MessageEntity) {
+	sort.SliceStable(w.entities, func(i, j int) bool {
+		if w.entities[i].Offset != w.entities[j].Offset {
+			return w.entities[i].Offset < w.entities[j].Offset
+		}
+		return w.entities[i].Length > w.entities[j].Length
+	})
+	return w.sb.String(), w.entities
+}
+
+type openEntity struct {
+	entity models.MessageEntity
+	start  int
+	tag    string
+}
+
+var htmlAttrRegex = regexp.MustCompile(`([a-zA-Z][\w-]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+
+var htmlUnescaper = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&", "&quot;", `"`)
+
+// ParseHTML parses text using the HTML parse mode rules.
+func ParseHTML(text string) (string, []models.MessageEntity, error) {
+	var w entityWriter
+	var stack []openEntity
+
+	for i := 0; i < len(text); {
+		switch text[i] {
+		case '<':
+			end := strings.IndexByte(text[i:], '>')
+			if end < 0 {
+				return "", nil, fmt.Errorf("unclosed tag at byte %d", i)
+			}
+			raw := strings.TrimSpace(text[i+1 : i+end])
+			i += end + 1
+
+			if strings.HasPrefix(raw, "/") {
+				name := strings.ToLower(strings.TrimSpace(raw[1:]))
+				if len(stack) == 0 || stack[len(stack)-1].tag != name {
+					return "", nil, fmt.Errorf("unexpected end tag </%s>", name)
+				}
+				open := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if open.entity.Type != "" {
+					w.add(open.entity, open.start)
+				}
+				continue
+			}
+
+			name, attrs := parseHTMLTag(raw)
+			entity, err := htmlTagEntity(name, attrs)
+			if err != nil {
+				return "", nil, err
+			}
+
+			// <pre><code class="language-x"> sets the language of the pre
+			// block instead of creating a nested code entity
+			if name == "code" && len(stack) > 0 && stack[len(stack)-1].tag == "pre" && stack[len(stack)-1].start == w.offset {
+				pre := &stack[len(stack)-1].entity
+				pre.Language = strings.TrimPrefix(attrs["class"], "language-")
+				entity = models.MessageEntity{}
+			}
+			stack = append(stack, openEntity{entity: entity, start: w.offset, tag: name})
+		case '&':
+			end := strings.IndexByte(text[i:], ';')
+			if end < 0 || end > 10 {
+				w.write("&")
+				i++
+				continue
+			}
+			w.write(unescapeHTMLEntity(text[i : i+end+1]))
+			i += end + 1
+		default:
+			next := strings.IndexAny(text[i:], "<&")
+			if next < 0 {
+				next = len(text) - i
+			}
+			w.write(text[i : i+next])
+			i += next
+		}
+	}
+
+	if len(stack) > 0 {
+		return "", nil, fmt.Errorf("unclosed tag <%s>", stack[len(stack)-1].tag)
+	}
+	text, entities := w.result()
+	return text, entities, nil
+}
+
+func parseHTMLTag(raw string) (string, map[string]string) {
+	raw = strings.TrimSuffix(raw, "/")
+	name, rest, _ := strings.Cut(raw, " ")
+	attrs := make(map[string]string)
+	for _, m := range htmlAttrRegex.FindAllStringSubmatch(rest, -1) {
+		attrs[strings.ToLower(m[1])] = htmlUnescaper.Replace(m[2] + m[3] + m[4])
+	}
+	return strings.ToLower(name), attrs
+}
+
+func htmlTagEntity(name string, attrs map[string]string) (models.MessageEntity, error) {
+	switch name {
+	case "b", "strong":
+		return models.MessageEntity{Type: models.EntityBold}, nil
+	case "i", "em":
+		return models.MessageEntity{Type: models.EntityItalic}, nil
+	case "u", "ins":
+		return models.MessageEntity{Type: models.EntityUnderline}, nil
+	case "s", "strike", "del":
+		return models.MessageEntity{Type: models.EntityStrikethrough}, nil
+	case "tg-spoiler":
+		return models.MessageEntity{Type: models.EntitySpoiler}, nil
+	case "span":
+		if attrs["class"] != "tg-spoiler" {
+			return models.MessageEntity{}, fmt.Errorf("unsupported span class %q", attrs["class"])
+		}
+		return models.MessageEntity{Type: models.EntitySpoiler}, nil
+	case "code":
+		return models.MessageEntity{Type: models.EntityCode}, nil
+	case "pre":
+		return models.MessageEntity{Type: models.EntityPre}, nil
+	case "blockquote":
+		if _, ok := attrs["expandable"]; ok {
+			return models.MessageEntity{Type: models.EntityExpandableBlockquote}, nil
+		}
+		return models.MessageEntity{Type: models.EntityBlockquote}, nil
+	case "a":
+		return linkEntity(attrs["href"]), nil
+	case "tg-emoji":
+		return models.MessageEntity{Type: models.EntityCustomEmoji, CustomEmojiId: attrs["emoji-id"]}, nil
+	default:
+		return models.MessageEntity{}, fmt.Errorf("unsupported tag <%s>", name)
+	}
+}
+
+func linkEntity(url string) models.MessageEntity {
+	if id, ok := strings.CutPrefix(url, "tg://user?id="); ok {
+		if userID, err := strconv.ParseInt(id, 10, 64); err == nil {
+			return models.MessageEntity{Type: models.EntityTextMention, User: &models.User{Id: userID}}
+		}
+	}
+	return models.MessageEntity{Type: models.EntityTextLink, URL: url}
+}
+
+func unescapeHTMLEntity(s string) string {
+	switch s {
+	case "&lt;":
+		return "<"
+	case "&gt;":
+		return ">"
+	case "&amp;":
+		return "&"
+	case "&quot;":
+		return `"`
+	}
+	if num, ok := strings.CutPrefix(s[:len(s)-1], "&#"); ok {
+		base := 10
+		if strings.HasPrefix(num, "x") || strings.HasPrefix(num, "X") {
+			num, base = num[1:], 16
+		}
+		if n, err := strconv.ParseInt(num, base, 32); err == nil {
+			return string(rune(n))
+		}
+	}
+	return s
+}
+
+// ParseMarkdownV2 parses text using the MarkdownV2 parse mode rules.
+func ParseMarkdownV2(text string) (string, []models.MessageEntity, error) {
+	var w entityWriter
+	var stack []openEntity
+	var quote *openEntity
+
+	// closes the innermost open entity of the given type, reporting whether
+	// one was found
+	closeEntity := func(typ string) bool {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].entity.Type == typ {
+				w.add(stack[i].entity, stack[i].start)
+				stack = append(stack[:i], stack[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+	toggle := func(typ string) {
+		if !closeEntity(typ) {
+			stack = append(stack, openEntity{entity: models.MessageEntity{Type: typ}, start: w.offset})
+		}
+	}
+
+	lineStart := true
+	for i := 0; i < len(text); {
+		if lineStart {
+			lineStart = false
+			switch {
+			case strings.HasPrefix(text[i:], "**>"):
+				quote = &openEntity{entity: models.MessageEntity{Type: models.EntityExpandableBlockquote}, start: w.offset}
+				i += 3
+				continue
+			case text[i] == '>':
+				if quote == nil {
+					quote = &openEntity{entity: models.MessageEntity{Type: models.EntityBlockquote}, start: w.offset}
+				}
+				i++
+				continue
+			case quote != nil:
+				// a line without ">" ends the quote before the line break
+				w.addRange(quote.entity, quote.start, w.offset-1)
+				quote = nil
+			}
+		}
+
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text):
+			_, size := utf8.DecodeRuneInString(text[i+1:])
+			w.write(text[i+1 : i+1+size])
+			i += 1 + size
+		case c == '\r':
+			i++
+		case c == '\n':
+			w.write("\n")
+			lineStart = true
+			i++
+		case strings.HasPrefix(text[i:], "```"):
+			end := indexUnescaped(text, i+3, "```")
+			if end < 0 {
+				return "", nil, fmt.Errorf("unclosed pre block at byte %d", i)
+			}
+			body := text[i+3 : end]
+			lang := ""
+			if nl := strings.IndexByte(body, '\n'); nl >= 0 && !strings.ContainsAny(body[:nl], " `") {
+				lang, body = body[:nl], body[nl+1:]
+			}
+			body = strings.TrimSuffix(unescapeMarkdownV2Code(body), "\n")
+			start := w.offset
+			w.write(body)
+			w.add(models.MessageEntity{Type: models.EntityPre, Language: lang}, start)
+			i = end + 3
+		case c == '`':
+			end := indexUnescaped(text, i+1, "`")
+			if end < 0 {
+				return "", nil, fmt.Errorf("unclosed code entity at byte %d", i)
+			}
+			start := w.offset
+			w.write(unescapeMarkdownV2Code(text[i+1 : end]))
+			w.add(models.MessageEntity{Type: models.EntityCode}, start)
+			i = end + 1
+		case strings.HasPrefix(text[i:], "||"):
+			if quote != nil && quote.entity.Type == models.EntityExpandableBlockquote &&
+				(i+2 == len(text) || text[i+2] == '\n') {
+				w.add(quote.entity, quote.start)
+				quote = nil
+			} else {
+				toggle(models.EntitySpoiler)
+			}
+			i += 2
+		case strings.HasPrefix(text[i:], "__"):
+			toggle(models.EntityUnderline)
+			i += 2
+		case c == '_':
+			toggle(models.EntityItalic)
+			i++
+		case c == '*':
+			toggle(models.EntityBold)
+			i++
+		case c == '~':
+			toggle(models.EntityStrikethrough)
+			i++
+		case strings.HasPrefix(text[i:], "!["):
+			stack = append(stack, openEntity{entity: models.MessageEntity{Type: models.EntityCustomEmoji}, start: w.offset, tag: "["})
+			i += 2
+		case c == '[':
+			stack = append(stack, openEntity{start: w.offset, tag: "["})
+			i++
+		case c == ']' && strings.HasPrefix(text[i:], "](") && hasOpenLink(stack):
+			end := indexUnescaped(text, i+2, ")")
+			if end < 0 {
+				return "", nil, fmt.Errorf("unclosed link url at byte %d", i)
+			}
+			url := markdownV2URLUnescaper.Replace(text[i+2 : end])
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].tag != "[" {
+					continue
+				}
+				entity := stack[j].entity
+				if entity.Type == models.EntityCustomEmoji {
+					entity.CustomEmojiId = strings.TrimPrefix(url, "tg://emoji?id=")
+				} else {
+					entity = linkEntity(url)
+				}
+				w.add(entity, stack[j].start)
+				stack = append(stack[:j], stack[j+1:]...)
+				break
+			}
+			i = end + 1
+		default:
+			next := i + 1
+			for next < len(text) && !strings.ContainsRune("\\\r\n`|_*~![]", rune(text[next])) {
+				next++
+			}
+			w.write(text[i:next])
+			i = next
+		}
+	}
+
+	switch {
+	case quote != nil && lineStart:
+		// the final line break ends the quote like a line without ">"
+		w.addRange(quote.entity, quote.start, w.offset-1)
+	case quote != nil:
+		w.add(quote.entity, quote.start)
+	}
+	if len(stack) > 0 {
+		typ := stack[len(stack)-1].entity.Type
+		if typ == "" {
+			typ = models.EntityTextLink
+		}
+		return "", nil, fmt.Errorf("can't find end of %s entity", typ)
+	}
+	text, entities := w.result()
+	return text, entities, nil
+}
+
+// indexUnescaped returns the byte index of the first occurrence of sep at or
+// after from that is not preceded by a backslash escape, or -1.
+func indexUnescaped(text string, from int, sep string) int {
+	for i := from; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	markdownV2CodeUnescaper = strings.NewReplacer(`\\`, `\`, "\\`", "`")
+	markdownV2URLUnescaper  = strings.NewReplacer(`\\`, `\`, `\)`, ")")
+)
+
+func unescapeMarkdownV2Code(s string) string {
+	return markdownV2CodeUnescaper.Replace(s)
+}
+
+func hasOpenLink(stack []openEntity) bool {
+	for _, open := range stack {
+		if open.tag == "[" {
+			return true
+		}
+	}
+	return false
+}
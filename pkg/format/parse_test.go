@@ -0,0 +1,120 @@
+package format
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+func TestParseHTML(t *testing.T) {
+	text, entities, err := ParseHTML(`👋 <b>bold <i>both</i></b> <a href="https://x.org/?a=1&amp;b=2">link</a> &lt;tag&gt; <pre><code class="language-go">x</code></pre>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "👋 bold both link <tag> x"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	want := []models.MessageEntity{
+		{Type: models.EntityBold, Offset: 3, Length: 9},
+		{Type: models.EntityItalic, Offset: 8, Length: 4},
+		{Type: models.EntityTextLink, Offset: 13, Length: 4, URL: "https://x.org/?a=1&b=2"},
+		{Type: models.EntityPre, Offset: 24, Length: 1, Language: "go"},
+	}
+	if !reflect.DeepEqual(entities, want) {
+		t.Errorf("entities = %+v, want %+v", entities, want)
+	}
+}
+
+func TestParseMarkdownV2(t *testing.T) {
+	text, entities, err := ParseMarkdownV2("*bold _both_* __under__ [link](https://x.org/a\\)b) `co\\`de` 1\\.5\n>quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "bold both under link co`de 1.5\nquote"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	want := []models.MessageEntity{
+		{Type: models.EntityBold, Offset: 0, Length: 9},
+		{Type: models.EntityItalic, Offset: 5, Length: 4},
+		{Type: models.EntityUnderline, Offset: 10, Length: 5},
+		{Type: models.EntityTextLink, Offset: 16, Length: 4, URL: "https://x.org/a)b"},
+		{Type: models.EntityCode, Offset: 21, Length: 5},
+		{Type: models.EntityBlockquote, Offset: 31, Length: 5},
+	}
+	if !reflect.DeepEqual(entities, want) {
+		t.Errorf("entities = %+v, want %+v", entities, want)
+	}
+}
+
+func TestParseUnknownMode(t *testing.T) {
+	text, entities, err := Parse("", "*not bold*")
+	if err != nil || text != "*not bold*" || entities != nil {
+		t.Errorf("got %q %v %v", text, entities, err)
+	}
+}
+
+// Rendering parsed text gives back text that parses the same way.
+func TestParseRenderRoundTrip(t *testing.T) {
+	inputs := map[string]string{
+		ModeHTML:       `<b>a <i>b</i></b> <u>c</u> <s>d</s> <tg-spoiler>e</tg-spoiler> <code>f&lt;</code> <a href="https://x.org">g</a> <tg-emoji emoji-id="5368324170671202286">👍</tg-emoji>`,
+		ModeMarkdownV2: "*a _b_* __c__ ~d~ ||e|| `f` [g](https://x.org) ![👍](tg://emoji?id=5368324170671202286)\n```py\nprint(1)\n```",
+	}
+	for mode, input := range inputs {
+		text, entities, err := Parse(mode, input)
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		for _, target := range []string{ModeHTML, ModeMarkdownV2} {
+			again, againEntities, err := Parse(target, EntitiesTo(target, text, entities))
+			if err != nil {
+				t.Fatalf("%s -> %s: %v", mode, target, err)
+			}
+			if again != text || !reflect.DeepEqual(againEntities, entities) {
+				t.Errorf("%s -> %s: got %q %+v, want %q %+v", mode, target, again, againEntities, text, entities)
+			}
+		}
+	}
+}
+
+func TestBlockquoteAfterText(t *testing.T) {
+	b := New().Text("see ").Blockquote("q")
+	if got, want := b.MarkdownV2(), "see \n>q\n"; got != want {
+		t.Errorf("MarkdownV2() = %q, want %q", got, want)
+	}
+
+	text, entities, err := ParseMarkdownV2(b.MarkdownV2())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantText, wantEntities := b.Entities()
+	if text != wantText || !reflect.DeepEqual(entities, wantEntities) {
+		t.Errorf("parsed %q %+v, want %q %+v", text, entities, wantText, wantEntities)
+	}
+}
+
+// Rendered output parses back to the builder's text and entities.
+func TestBuilderRoundTrip(t *testing.T) {
+	b := New().
+		Line("Report").
+		Bold("bold").Text(" ").Italic("italic").Text(" ").Underline("under").Text(" ").
+		Strikethrough("strike").Text(" ").Spoiler("spoiler").Text("\n").
+		Code("code`\\").Text(" ").Link("a (link)", "https://t.me/x?a=(1)").Text("\n").
+		Pre("", "  indented\n\tcode").Text("\n").
+		Text("see ").Blockquote("quoted\nlines").
+		Text("1 + 1 = 2. Done!")
+
+	wantText, wantEntities := b.Entities()
+	for _, mode := range []string{ModeHTML, ModeMarkdownV2} {
+		text, entities, err := Parse(mode, b.Render(mode))
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		if text != wantText {
+			t.Errorf("%s: text = %q, want %q", mode, text, wantText)
+		}
+		if !reflect.DeepEqual(entities, wantEntities) {
+			t.Errorf("%s: entities = %+v, want %+v", mode, entities, wantEntities)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package format
+
+import (
+	"unicode/utf16"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// Limits of the Bot API, measured after entity parsing.
+const (
+	MaxMessageLength = 4096
+	MaxCaptionLength = 1024
+)
+
+// Chunk is one part of a split message.
+type Chunk struct {
+	Text     string
+	Entities []models.MessageEntity
+}
+
+// split points in order of preference
+var separators = [][]uint16{
+	utf16.Encode([]rune("\n\n")),
+	utf16.Encode([]rune("\n")),
+	utf16.Encode([]rune(" ")),
+}
+
+// Split breaks text into chunks of at most limit UTF-16 code units. It cuts
+// at paragraph, then line, then word boundaries, preferring points outside
+// of any entity. Entities that can't be kept whole are split so their
+// formatting continues in the next chunk.
+func Split(text string, entities []models.MessageEntity, limit int) []Chunk {
+	units := utf16.Encode([]rune(text))
+	if limit <= 0 || len(units) <= limit {
+		return []Chunk{{Text: text, Entities: entities}}
+	}
+
+	var chunks []Chunk
+	for start := 0; start < len(units); {
+		stop := len(units)
+		if stop-start > limit {
+			stop = cutPoint(units, entities, start, start+limit)
+		}
+		if chunk, ok := makeChunk(units, entities, start, stop); ok {
+			chunks = append(chunks, chunk)
+		}
+		start = stop
+	}
+	return chunks
+}
+
+// SplitCaption splits a media caption into the part sent with the media, at
+// most MaxCaptionLength long, and the overflow to send as follow-up messages
+// of at most MaxMessageLength. The overflow is nil when the caption fits.
+func SplitCaption(text string, entities []models.MessageEntity) (Chunk, []Chunk) {
+	units := utf16.Encode([]rune(text))
+	if len(units) <= MaxCaptionLength {
+		return Chunk{Text: text, Entities: entities}, nil
+	}
+
+	stop := cutPoint(units, entities, 0, MaxCaptionLength)
+	caption, _ := makeChunk(units, entities, 0, stop)
+	rest, ok := makeChunk(units, entities, stop, len(units))
+	if !ok {
+		return caption, nil
+	}
+	return caption, Split(rest.Text, rest.Entities, MaxMessageLength)
+}
+
+// SplitFormatted parses text for the given parse mode and splits it, the
+// resulting chunks carry entities and must be sent without a parse mode.
+func SplitFormatted(mode, text string, limit int) ([]Chunk, error) {
+	plain, entities, err := Parse(mode, text)
+	if err != nil {
+		return nil, err
+	}
+	return Split(plain, entities, limit), nil
+}
+
+func cutPoint(units []uint16, entities []models.MessageEntity, start, max int) int {
+	// avoid tiny chunks by looking at the second half of the window first
+	half := start + (max-start)/2
+	for _, from := range []int{half, start} {
+		for _, sep := range separators {
+			for p := max; p > from; p-- {
+				if hasSuffix(units[:p], sep) && !insideEntity(entities, p) {
+					return p
+				}
+			}
+		}
+	}
+
+	// every boundary is inside an entity, split the entity
+	for _, sep := range separators {
+		for p := max; p > half; p-- {
+			if hasSuffix(units[:p], sep) {
+				return p
+			}
+		}
+	}
+
+	// no usable boundary, cut at the limit without splitting a surrogate pair
+	if utf16.IsSurrogate(rune(units[max-1])) && units[max-1] < 0xdc00 && max-1 > start {
+		return max - 1
+	}
+	return max
+}
+
+func hasSuffix(units, suffix []uint16) bool {
+	if len(units) < len(suffix) {
+		return false
+	}
+	for i := range suffix {
+		if units[len(units)-len(suffix)+i] != suffix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func insideEntity(entities []models.MessageEntity, p int) bool {
+	for _, e := range entities {
+		if e.Offset < p && p < e.Offset+e.Length {
+			return true
+		}
+	}
+	return false
+}
+
+func isSpace(u uint16) bool {
+	return u == ' ' || u == '\n' || u == '\t' || u == '\r'
+}
+
+// insideCode reports whether unit i is part of a code or pre entity, where
+// whitespace such as indentation is meaningful.
+func insideCode(entities []models.MessageEntity, i int) bool {
+	for _, e := range entities {
+		if (e.Type == models.EntityCode || e.Type == models.EntityPre) && e.Offset <= i && i < e.Offset+e.Length {
+			return true
+		}
+	}
+	return false
+}
+
+// makeChunk cuts [start, stop) out of units, trimming whitespace outside of
+// code at its ends since the Bot API strips it and would shift the entity
+// offsets.
+func makeChunk(units []uint16, entities []models.MessageEntity, start, stop int) (Chunk, bool) {
+	for start < stop && isSpace(units[start]) && !insideCode(entities, start) {
+		start++
+	}
+	for stop > start && isSpace(units[stop-1]) && !insideCode(entities, stop-1) {
+		stop--
+	}
+	if start == stop {
+		return Chunk{}, false
+	}
+
+	chunk := Chunk{Text: string(utf16.Decode(units[start:stop]))}
+	for _, e := range entities {
+		from, to := e.Offset, e.Offset+e.Length
+		if from < start {
+			from = start
+		}
+		if to > stop {
+			to = stop
+		}
+		if from >= to {
+			continue
+		}
+		e.Offset, e.Length = from-start, to-from
+		chunk.Entities = append(chunk.Entities, e)
+	}
+	return chunk, true
+}
@@ -0,0 +1,124 @@
+package format
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+func TestSplitFits(t *testing.T) {
+	entities := []models.MessageEntity{{Type: models.EntityBold, Offset: 0, Length: 2}}
+	chunks := Split("hi there", entities, 10)
+	if want := []Chunk{{Text: "hi there", Entities: entities}}; !reflect.DeepEqual(chunks, want) {
+		t.Errorf("got %+v, want %+v", chunks, want)
+	}
+}
+
+func TestSplitPrefersParagraphs(t *testing.T) {
+	text := "first line\nsame paragraph\n\nsecond paragraph"
+	chunks := Split(text, nil, 30)
+	want := []Chunk{{Text: "first line\nsame paragraph"}, {Text: "second paragraph"}}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("got %+v, want %+v", chunks, want)
+	}
+}
+
+// Limits count UTF-16 code units, each emoji here takes two.
+func TestSplitUTF16(t *testing.T) {
+	text := strings.Repeat("👋", 3) + " " + strings.Repeat("👋", 3)
+	chunks := Split(text, nil, 8)
+	if len(chunks) != 2 || chunks[0].Text != "👋👋👋" || chunks[1].Text != "👋👋👋" {
+		t.Errorf("got %+v", chunks)
+	}
+	for _, c := range chunks {
+		if n := UTF16Len(c.Text); n > 8 {
+			t.Errorf("chunk %q is %d units long", c.Text, n)
+		}
+	}
+}
+
+// Cuts avoid entities when possible, an entity that can't be kept whole
+// continues in the next chunk with shifted offsets.
+func TestSplitEntities(t *testing.T) {
+	chunks := Split("aa bbbb cccc dd", []models.MessageEntity{{Type: models.EntityBold, Offset: 3, Length: 4}}, 8)
+	want := []Chunk{
+		{Text: "aa bbbb", Entities: []models.MessageEntity{{Type: models.EntityBold, Offset: 3, Length: 4}}},
+		{Text: "cccc dd"},
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("avoided: got %+v, want %+v", chunks, want)
+	}
+
+	chunks = Split("aaaa bbbb cccc", []models.MessageEntity{{Type: models.EntityBold, Offset: 0, Length: 14}}, 10)
+	want = []Chunk{
+		{Text: "aaaa bbbb", Entities: []models.MessageEntity{{Type: models.EntityBold, Offset: 0, Length: 9}}},
+		{Text: "cccc", Entities: []models.MessageEntity{{Type: models.EntityBold, Offset: 0, Length: 4}}},
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("got %+v, want %+v", chunks, want)
+	}
+}
+
+// Indentation inside pre blocks is kept when a chunk starts or ends in one.
+func TestSplitKeepsCodeIndentation(t *testing.T) {
+	text := "intro\n\n    indented code\n    more"
+	entities := []models.MessageEntity{{Type: models.EntityPre, Offset: 7, Length: 26}}
+	chunks := Split(text, entities, 20)
+	if len(chunks) < 2 {
+		t.Fatalf("got %+v", chunks)
+	}
+	if !strings.HasPrefix(chunks[1].Text, "    indented") {
+		t.Errorf("second chunk %q lost its indentation", chunks[1].Text)
+	}
+	if e := chunks[1].Entities; len(e) != 1 || e[0].Offset != 0 {
+		t.Errorf("entities = %+v", e)
+	}
+}
+
+func TestSplitCaption(t *testing.T) {
+	short, rest := SplitCaption("short", nil)
+	if short.Text != "short" || rest != nil {
+		t.Errorf("got %+v %+v", short, rest)
+	}
+
+	word := strings.Repeat("a", 99) + " "
+	text := strings.TrimSpace(strings.Repeat(word, 60)) // 5999 units
+	entities := []models.MessageEntity{{Type: models.EntityItalic, Offset: 0, Length: 5999}}
+	caption, overflow := SplitCaption(text, entities)
+
+	if n := UTF16Len(caption.Text); n > MaxCaptionLength || n < MaxCaptionLength-100 {
+		t.Errorf("caption is %d units long", n)
+	}
+	if len(overflow) != 2 {
+		t.Fatalf("got %d overflow chunks, want 2", len(overflow))
+	}
+	total := UTF16Len(caption.Text)
+	for _, c := range overflow {
+		if n := UTF16Len(c.Text); n > MaxMessageLength {
+			t.Errorf("overflow chunk is %d units long", n)
+		}
+		total += UTF16Len(c.Text) + 1 // the space cut at
+	}
+	if total != UTF16Len(text) {
+		t.Errorf("chunks hold %d units, want %d", total, UTF16Len(text))
+	}
+	if len(caption.Entities) != 1 || len(overflow[0].Entities) != 1 || overflow[0].Entities[0].Offset != 0 {
+		t.Errorf("entity not continued: %+v / %+v", caption.Entities, overflow[0].Entities)
+	}
+}
+
+func TestSplitFormatted(t *testing.T) {
+	chunks, err := SplitFormatted(ModeHTML, "<b>one two</b> three", 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Chunk{
+		{Text: "one two", Entities: []models.MessageEntity{{Type: models.EntityBold, Offset: 0, Length: 7}}},
+		{Text: "three"},
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("got %+v, want %+v", chunks, want)
+	}
+}
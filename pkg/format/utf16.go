@@ -0,0 +1,34 @@
+package format
+
+import "unicode/utf16"
+
+// UTF16Len returns the length of s in UTF-16 code units, the unit Telegram
+// uses for entity offsets and lengths.
+func UTF16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// UTF16Slice returns the part of s starting at offset and spanning length
+// UTF-16 code units. Out of range bounds are clamped.
+func UTF16Slice(s string, offset, length int) string {
+	units := utf16.Encode([]rune(s))
+	start, end := clamp(offset, len(units)), clamp(offset+length, len(units))
+	if start >= end {
+		return ""
+	}
+	return string(utf16.Decode(units[start:end]))
+}
+
+func clamp(n, max int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
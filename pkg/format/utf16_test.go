@@ -0,0 +1,42 @@
+package format
+
+import "testing"
+
+func TestUTF16Len(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"привет", 6},
+		{"👋", 2},
+		{"a👍🏽b", 6}, // emoji with a skin tone modifier is two surrogate pairs
+		{"日本", 2},
+	}
+	for _, tt := range tests {
+		if got := UTF16Len(tt.in); got != tt.want {
+			t.Errorf("UTF16Len(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUTF16Slice(t *testing.T) {
+	s := "👋 hi 🌍!"
+	tests := []struct {
+		offset, length int
+		want           string
+	}{
+		{0, 2, "👋"},
+		{3, 2, "hi"},
+		{6, 3, "🌍!"},
+		{6, 100, "🌍!"},
+		{-5, 7, "👋"},
+		{20, 3, ""},
+	}
+	for _, tt := range tests {
+		if got := UTF16Slice(s, tt.offset, tt.length); got != tt.want {
+			t.Errorf("UTF16Slice(%d, %d) = %q, want %q", tt.offset, tt.length, got, tt.want)
+		}
+	}
+}
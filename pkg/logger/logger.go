@@ -2,18 +2,30 @@ package logger
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Logger is a structured logger. Messages are constant strings, variable
+// data goes into alternating key/value pairs:
+//
+//	log.Error("Failed to send message", "chat_id", chatID, "error", err)
 type Logger interface {
-	Info(format string, args ...any)
-	Error(format string, args ...any)
-	Debug(format string, args ...any)
-	Warn(format string, args ...any)
+	Info(msg string, keysAndValues ...any)
+	Error(msg string, keysAndValues ...any)
+	Debug(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
 }
 
+// DefaultLogger writes colored lines with key=value fields to stderr.
 type DefaultLogger struct {
 	level LogLevel
+
+	mu  sync.Mutex
+	out io.Writer
 }
 
 type LogLevel int
@@ -28,9 +40,17 @@ const (
 func NewDefaultLogger(level LogLevel) *DefaultLogger {
 	return &DefaultLogger{
 		level: level,
+		out:   os.Stderr,
 	}
 }
 
+// SetOutput sets where log lines are written, stderr by default.
+func (l *DefaultLogger) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out = w
+}
+
 const (
 	colorRed    = "\033[31m"
 	colorGreen  = "\033[32m"
@@ -39,38 +59,63 @@ const (
 	colorReset  = "\033[0m"
 )
 
-func (l *DefaultLogger) Info(format string, args ...any) {
+func (l *DefaultLogger) Info(msg string, keysAndValues ...any) {
 	if l.level <= INFO {
-		l.log("INFO", colorGreen, format, args...)
+		l.log("INFO", colorGreen, msg, keysAndValues)
 	}
 }
 
-func (l *DefaultLogger) Warn(format string, args ...any) {
+func (l *DefaultLogger) Warn(msg string, keysAndValues ...any) {
 	if l.level <= WARN {
-		l.log("WARN", colorYellow, format, args...)
+		l.log("WARN", colorYellow, msg, keysAndValues)
 	}
 }
 
-func (l *DefaultLogger) Debug(format string, args ...any) {
+func (l *DefaultLogger) Debug(msg string, keysAndValues ...any) {
 	if l.level <= DEBUG {
-		l.log("DEBUG", colorBlue, format, args...)
+		l.log("DEBUG", colorBlue, msg, keysAndValues)
 	}
 }
 
-func (l *DefaultLogger) Error(format string, args ...any) {
+func (l *DefaultLogger) Error(msg string, keysAndValues ...any) {
 	if l.level <= ERROR {
-		l.log("ERROR", colorRed, format, args...)
+		l.log("ERROR", colorRed, msg, keysAndValues)
 	}
 }
 
-func (l *DefaultLogger) log(level, color, format string, args ...any) {
+func (l *DefaultLogger) log(level, color, msg string, keysAndValues []any) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	var message string
-	if len(args) > 0 {
-		message = fmt.Sprintf(format, args...)
-	} else {
-		message = format // handle literal string
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s[%s] %s: %s", color, timestamp, level, msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, val := "!BADKEY", keysAndValues[i]
+		if i+1 < len(keysAndValues) {
+			key, val = fmt.Sprint(keysAndValues[i]), keysAndValues[i+1]
+		}
+		fmt.Fprintf(&sb, " %s=%s", key, formatValue(val))
 	}
+	sb.WriteString(colorReset)
+	sb.WriteByte('\n')
 
-	fmt.Printf("%s[%s] %s: %s%s\n", color, timestamp, level, message, colorReset)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.out, sb.String())
 }
+
+// formatValue quotes values that wouldn't read as a single token.
+func formatValue(val any) string {
+	s := fmt.Sprint(val)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// NopLogger discards everything.
+type NopLogger struct{}
+
+func (NopLogger) Info(string, ...any)  {}
+func (NopLogger) Error(string, ...any) {}
+func (NopLogger) Debug(string, ...any) {}
+func (NopLogger) Warn(string, ...any)  {}
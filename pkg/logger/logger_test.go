@@ -0,0 +1,88 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx/pkg/logger"
+)
+
+func TestDefaultLoggerFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := logger.NewDefaultLogger(logger.DEBUG)
+	l.SetOutput(&buf)
+
+	l.Error("Failed to send message", "chat_id", 42, "error", errors.New("bad request"), "odd")
+
+	line := buf.String()
+	for _, want := range []string{"ERROR: Failed to send message", "chat_id=42", `error="bad request"`, "!BADKEY=odd"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("line %q doesn't contain %q", line, want)
+		}
+	}
+	if !strings.HasSuffix(line, "\n") || strings.Count(line, "\n") != 1 {
+		t.Errorf("line %q isn't a single line", line)
+	}
+}
+
+func TestDefaultLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := logger.NewDefaultLogger(logger.WARN)
+	l.SetOutput(&buf)
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+
+	out := buf.String()
+	if strings.Contains(out, "debug") || strings.Contains(out, "info") {
+		t.Errorf("messages below WARN were written: %q", out)
+	}
+	if !strings.Contains(out, "WARN: warn") || !strings.Contains(out, "ERROR: error") {
+		t.Errorf("missing messages: %q", out)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	l := logger.NewSlogLogger(handler).With("bot", "test")
+
+	l.Warn("Rate limited", "method", "sendMessage", "retry_after", 3)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid record %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level":       "WARN",
+		"msg":         "Rate limited",
+		"bot":         "test",
+		"method":      "sendMessage",
+		"retry_after": float64(3),
+	}
+	for key, val := range want {
+		if record[key] != val {
+			t.Errorf("%s = %v, want %v", key, record[key], val)
+		}
+	}
+}
+
+func TestSlogLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := logger.NewSlogLogger(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	l.Debug("hidden")
+	l.Info("shown")
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+var _ logger.Logger = logger.NopLogger{}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLogger logs through a slog.Handler, so the library's logs end up with
+// the application's.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+func NewSlogLogger(handler slog.Handler) *SlogLogger {
+	return &SlogLogger{logger: slog.New(handler)}
+}
+
+// With returns a logger adding the given key/value pairs to every record.
+func (l *SlogLogger) With(keysAndValues ...any) *SlogLogger {
+	return &SlogLogger{logger: l.logger.With(keysAndValues...)}
+}
+
+func (l *SlogLogger) Info(msg string, keysAndValues ...any) {
+	l.logger.Log(context.Background(), slog.LevelInfo, msg, keysAndValues...)
+}
+
+func (l *SlogLogger) Error(msg string, keysAndValues ...any) {
+	l.logger.Log(context.Background(), slog.LevelError, msg, keysAndValues...)
+}
+
+func (l *SlogLogger) Debug(msg string, keysAndValues ...any) {
+	l.logger.Log(context.Background(), slog.LevelDebug, msg, keysAndValues...)
+}
+
+func (l *SlogLogger) Warn(msg string, keysAndValues ...any) {
+	l.logger.Log(context.Background(), slog.LevelWarn, msg, keysAndValues...)
+}
@@ -0,0 +1,254 @@
+// Package metrics exposes a bot's metrics in the Prometheus text format.
+//
+//	m := metrics.NewPrometheus()
+//	bot.UseMetrics(m)
+//	http.Handle("/metrics", m)
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+)
+
+// DefaultBuckets are the upper bounds in seconds of latency histograms.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var _ tgx.Metrics = (*Prometheus)(nil)
+
+// Prometheus collects the metrics of a bot in memory and serves them in the
+// Prometheus text exposition format.
+type Prometheus struct {
+	mu       sync.Mutex
+	families []*family
+
+	updates          *family
+	handlerDuration  *family
+	handlerErrors    *family
+	apiCalls         *family
+	apiDuration      *family
+	apiRetries       *family
+	rateLimitWaits   *family
+	rateLimitSeconds *family
+	queueDepth       *family
+}
+
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{}
+	p.updates = p.add("tgx_updates_total", "Updates received by type.", counter, nil, "type")
+	p.handlerDuration = p.add("tgx_handler_duration_seconds", "Handler latency.", histogram, DefaultBuckets, "handler")
+	p.handlerErrors = p.add("tgx_handler_errors_total", "Handler runs that returned an error.", counter, nil, "handler")
+	p.apiCalls = p.add("tgx_api_calls_total", "Bot API calls by method and status, 0 when no response arrived.", counter, nil, "method", "status")
+	p.apiDuration = p.add("tgx_api_call_duration_seconds", "Bot API call latency.", histogram, DefaultBuckets, "method")
+	p.apiRetries = p.add("tgx_api_retries_total", "Bot API calls sent again after failing.", counter, nil, "method")
+	p.rateLimitWaits = p.add("tgx_rate_limit_waits_total", "Waits caused by rate limiting.", counter, nil, "method")
+	p.rateLimitSeconds = p.add("tgx_rate_limit_wait_seconds_total", "Time spent waiting because of rate limiting.", counter, nil, "method")
+	p.queueDepth = p.add("tgx_webhook_queue_depth", "Updates waiting in the worker pool.", gauge, nil)
+	return p
+}
+
+func (p *Prometheus) UpdateReceived(updateType string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.updates.get(updateType).value++
+}
+
+func (p *Prometheus) HandlerDone(handler string, duration time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlerDuration.get(handler).observe(duration.Seconds())
+	if err != nil {
+		p.handlerErrors.get(handler).value++
+	}
+}
+
+func (p *Prometheus) APICall(method string, status int, duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.apiCalls.get(method, strconv.Itoa(status)).value++
+	p.apiDuration.get(method).observe(duration.Seconds())
+}
+
+func (p *Prometheus) APIRetry(method string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.apiRetries.get(method).value++
+}
+
+func (p *Prometheus) RateLimitWait(method string, wait time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rateLimitWaits.get(method).value++
+	p.rateLimitSeconds.get(method).value += wait.Seconds()
+}
+
+func (p *Prometheus) QueueDepth(depth int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queueDepth.get().value = float64(depth)
+}
+
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.Write(w)
+}
+
+// Write writes all metrics in the text exposition format.
+func (p *Prometheus) Write(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range p.families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+type kind string
+
+const (
+	counter   kind = "counter"
+	gauge     kind = "gauge"
+	histogram kind = "histogram"
+)
+
+type family struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+
+	// histograms only, counts[i] counts observations <= buckets[i]
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func (p *Prometheus) add(name, help string, k kind, buckets []float64, labels ...string) *family {
+	f := &family{
+		name:    name,
+		help:    help,
+		kind:    k,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	p.families = append(p.families, f)
+	return f
+}
+
+func (f *family) get(labelValues ...string) *series {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: labelValues}
+		if f.kind == histogram {
+			s.buckets = f.buckets
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (s *series) observe(v float64) {
+	s.sum += v
+	s.count++
+	for i, bound := range s.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+}
+
+func (f *family) write(w *bufio.Writer) {
+	if len(f.series) == 0 && f.kind != gauge {
+		return
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		fmt.Fprintf(w, "%s 0\n", f.name)
+		return
+	}
+
+	for _, key := range keys {
+		s := f.series[key]
+		labels := formatLabels(f.labels, s.labelValues)
+		if f.kind != histogram {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, wrapLabels(labels), formatFloat(s.value))
+			continue
+		}
+		for i, bound := range f.buckets {
+			le := joinLabels(labels, fmt.Sprintf(`le="%s"`, formatFloat(bound)))
+			fmt.Fprintf(w, "%s_bucket{%s} %d\n", f.name, le, s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s} %d\n", f.name, joinLabels(labels, `le="+Inf"`), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, wrapLabels(labels), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, wrapLabels(labels), s.count)
+	}
+}
+
+func formatLabels(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escapeLabel(values[i]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func joinLabels(labels, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
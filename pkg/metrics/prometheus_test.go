@@ -0,0 +1,104 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx/pkg/metrics"
+)
+
+func exposition(t *testing.T, p *metrics.Prometheus) string {
+	t.Helper()
+	var sb strings.Builder
+	if err := p.Write(&sb); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestPrometheusCounters(t *testing.T) {
+	p := metrics.NewPrometheus()
+	p.UpdateReceived("message")
+	p.UpdateReceived("message")
+	p.UpdateReceived("callback_query")
+	p.APICall("sendMessage", 200, 10*time.Millisecond)
+	p.APICall("sendMessage", 429, 10*time.Millisecond)
+	p.APIRetry("sendMessage")
+	p.RateLimitWait("sendMessage", 1500*time.Millisecond)
+	p.QueueDepth(3)
+
+	out := exposition(t, p)
+	for _, want := range []string{
+		"# TYPE tgx_updates_total counter\n",
+		`tgx_updates_total{type="callback_query"} 1` + "\n",
+		`tgx_updates_total{type="message"} 2` + "\n",
+		`tgx_api_calls_total{method="sendMessage",status="200"} 1` + "\n",
+		`tgx_api_calls_total{method="sendMessage",status="429"} 1` + "\n",
+		`tgx_api_retries_total{method="sendMessage"} 1` + "\n",
+		`tgx_rate_limit_waits_total{method="sendMessage"} 1` + "\n",
+		`tgx_rate_limit_wait_seconds_total{method="sendMessage"} 1.5` + "\n",
+		"# TYPE tgx_webhook_queue_depth gauge\ntgx_webhook_queue_depth 3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exposition doesn't contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrometheusHistogram(t *testing.T) {
+	p := metrics.NewPrometheus()
+	p.HandlerDone("command:start", 20*time.Millisecond, nil)
+	p.HandlerDone("command:start", 2*time.Second, errors.New("failed"))
+
+	out := exposition(t, p)
+	for _, want := range []string{
+		"# TYPE tgx_handler_duration_seconds histogram\n",
+		`tgx_handler_duration_seconds_bucket{handler="command:start",le="0.01"} 0` + "\n",
+		`tgx_handler_duration_seconds_bucket{handler="command:start",le="0.025"} 1` + "\n",
+		`tgx_handler_duration_seconds_bucket{handler="command:start",le="2.5"} 2` + "\n",
+		`tgx_handler_duration_seconds_bucket{handler="command:start",le="+Inf"} 2` + "\n",
+		`tgx_handler_duration_seconds_sum{handler="command:start"} 2.02` + "\n",
+		`tgx_handler_duration_seconds_count{handler="command:start"} 2` + "\n",
+		`tgx_handler_errors_total{handler="command:start"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exposition doesn't contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrometheusEmpty(t *testing.T) {
+	out := exposition(t, metrics.NewPrometheus())
+	// only the gauge has a value before anything happened
+	if out != "# HELP tgx_webhook_queue_depth Updates waiting in the worker pool.\n# TYPE tgx_webhook_queue_depth gauge\ntgx_webhook_queue_depth 0\n" {
+		t.Errorf("exposition = %q", out)
+	}
+}
+
+func TestPrometheusEscapesLabels(t *testing.T) {
+	p := metrics.NewPrometheus()
+	p.HandlerDone("callback:\"a\\b\"\n", time.Millisecond, errors.New("failed"))
+
+	want := `tgx_handler_errors_total{handler="callback:\"a\\b\"\n"} 1`
+	if out := exposition(t, p); !strings.Contains(out, want) {
+		t.Errorf("exposition doesn't contain %q:\n%s", want, out)
+	}
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	p := metrics.NewPrometheus()
+	p.UpdateReceived("message")
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `tgx_updates_total{type="message"} 1`) {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
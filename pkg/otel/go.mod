@@ -0,0 +1,9 @@
+module github.com/harshyadavone/tgx/pkg/otel
+
+go 1.23.1
+
+require (
+	github.com/harshyadavone/tgx v0.0.0-20261018163843-c8c230a719b6
+	go.opentelemetry.io/otel v1.34.0
+	go.opentelemetry.io/otel/trace v1.34.0
+)
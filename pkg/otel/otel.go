@@ -0,0 +1,84 @@
+// Package otel traces a bot with OpenTelemetry. It is a module of its own,
+// so bots not using it don't depend on OpenTelemetry.
+//
+//	import tgxotel "github.com/harshyadavone/tgx/pkg/otel"
+//
+//	bot.UseTracer(tgxotel.NewTracer(otel.GetTracerProvider()))
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/harshyadavone/tgx"
+)
+
+// instrumentationName identifies the spans of this library
+const instrumentationName = "github.com/harshyadavone/tgx"
+
+var _ tgx.Tracer = (*Tracer)(nil)
+
+// Tracer adapts an OpenTelemetry TracerProvider to tgx.Tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: provider.Tracer(instrumentationName)}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...tgx.Attribute) (context.Context, tgx.Span) {
+	kind := trace.SpanKindInternal
+	if name == tgx.SpanAPICall {
+		kind = trace.SpanKindClient
+	}
+	ctx, s := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(convert(attrs)...),
+	)
+	return ctx, &span{span: s}
+}
+
+type span struct {
+	span trace.Span
+}
+
+func (s *span) SetAttributes(attrs ...tgx.Attribute) {
+	s.span.SetAttributes(convert(attrs)...)
+}
+
+func (s *span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *span) End() {
+	s.span.End()
+}
+
+func convert(attrs []tgx.Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		switch v := a.Value.(type) {
+		case nil:
+			continue
+		case string:
+			kvs = append(kvs, attribute.String(a.Key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(a.Key, v))
+		case int:
+			kvs = append(kvs, attribute.Int(a.Key, v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(a.Key, v))
+		case float64:
+			kvs = append(kvs, attribute.Float64(a.Key, v))
+		default:
+			kvs = append(kvs, attribute.String(a.Key, fmt.Sprint(v)))
+		}
+	}
+	return kvs
+}
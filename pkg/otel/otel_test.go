@@ -0,0 +1,131 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/harshyadavone/tgx"
+	tgxotel "github.com/harshyadavone/tgx/pkg/otel"
+)
+
+// recorder is a TracerProvider keeping the spans started through it.
+type recorder struct {
+	embedded.TracerProvider
+
+	name  string
+	spans []*recordedSpan
+}
+
+type recordingTracer struct {
+	embedded.Tracer
+	r *recorder
+}
+
+type recordedSpan struct {
+	trace.Span
+
+	name   string
+	kind   trace.SpanKind
+	parent trace.Span
+	attrs  map[attribute.Key]attribute.Value
+	status codes.Code
+	errs   []error
+	ended  bool
+}
+
+func (r *recorder) Tracer(name string, _ ...trace.TracerOption) trace.Tracer {
+	r.name = name
+	return recordingTracer{r: r}
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	s := &recordedSpan{
+		Span:   noop.Span{},
+		name:   name,
+		kind:   cfg.SpanKind(),
+		parent: trace.SpanFromContext(ctx),
+		attrs:  make(map[attribute.Key]attribute.Value),
+	}
+	s.SetAttributes(cfg.Attributes()...)
+	t.r.spans = append(t.r.spans, s)
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+func (s *recordedSpan) SetAttributes(kvs ...attribute.KeyValue) {
+	for _, kv := range kvs {
+		s.attrs[kv.Key] = kv.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error, _ ...trace.EventOption) { s.errs = append(s.errs, err) }
+func (s *recordedSpan) SetStatus(code codes.Code, _ string)           { s.status = code }
+func (s *recordedSpan) End(...trace.SpanEndOption)                    { s.ended = true }
+
+func TestTracer(t *testing.T) {
+	provider := &recorder{}
+	tracer := tgxotel.NewTracer(provider)
+	if provider.name != "github.com/harshyadavone/tgx" {
+		t.Errorf("instrumentation name = %q", provider.name)
+	}
+
+	ctx, update := tracer.Start(context.Background(), tgx.SpanUpdate,
+		tgx.Attr(tgx.AttrUpdateID, 7),
+		tgx.Attr(tgx.AttrChatID, int64(42)),
+		tgx.Attr(tgx.AttrUserID, nil),
+	)
+	_, call := tracer.Start(ctx, tgx.SpanAPICall, tgx.Attr(tgx.AttrMethod, "sendMessage"))
+	call.SetAttributes(tgx.Attr(tgx.AttrStatus, 400), tgx.Attr("ratio", 0.5), tgx.Attr("ok", false), tgx.Attr("other", []int{1}))
+	call.RecordError(errors.New("bad request"))
+	call.End()
+	update.End()
+
+	if len(provider.spans) != 2 {
+		t.Fatalf("got %d spans", len(provider.spans))
+	}
+	u, c := provider.spans[0], provider.spans[1]
+
+	if u.kind != trace.SpanKindInternal || c.kind != trace.SpanKindClient {
+		t.Errorf("kinds = %v, %v", u.kind, c.kind)
+	}
+	if c.parent != trace.Span(u) {
+		t.Error("the call span isn't a child of the update span")
+	}
+	if _, ok := u.attrs[tgx.AttrUserID]; ok {
+		t.Error("nil attribute was set")
+	}
+	want := map[attribute.Key]attribute.Value{
+		tgx.AttrUpdateID: attribute.IntValue(7),
+		tgx.AttrChatID:   attribute.Int64Value(42),
+	}
+	for key, val := range want {
+		if u.attrs[key] != val {
+			t.Errorf("update %s = %v, want %v", key, u.attrs[key].Emit(), val.Emit())
+		}
+	}
+	want = map[attribute.Key]attribute.Value{
+		tgx.AttrMethod: attribute.StringValue("sendMessage"),
+		tgx.AttrStatus: attribute.IntValue(400),
+		"ratio":        attribute.Float64Value(0.5),
+		"ok":           attribute.BoolValue(false),
+		"other":        attribute.StringValue("[1]"),
+	}
+	for key, val := range want {
+		if c.attrs[key] != val {
+			t.Errorf("call %s = %v, want %v", key, c.attrs[key].Emit(), val.Emit())
+		}
+	}
+	if c.status != codes.Error || len(c.errs) != 1 {
+		t.Errorf("call status = %v, errors = %v", c.status, c.errs)
+	}
+	if !u.ended || !c.ended {
+		t.Error("spans not ended")
+	}
+}
@@ -0,0 +1,110 @@
+package tgxtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// AssertCalled fails the test unless method was called at least once, and
+// returns the last call to it.
+func (s *Server) AssertCalled(tb testing.TB, method string) Call {
+	tb.Helper()
+	call, ok := s.LastCall(method)
+	if !ok {
+		tb.Fatalf("expected a call to %s, got %s", method, s.describeCalls())
+	}
+	return call
+}
+
+// AssertNotCalled fails the test when method was called.
+func (s *Server) AssertNotCalled(tb testing.TB, method string) {
+	tb.Helper()
+	if calls := s.CallsTo(method); len(calls) > 0 {
+		tb.Fatalf("expected no call to %s, got %d", method, len(calls))
+	}
+}
+
+// AssertCallCount fails the test unless method was called exactly n times.
+func (s *Server) AssertCallCount(tb testing.TB, method string, n int) {
+	tb.Helper()
+	if calls := s.CallsTo(method); len(calls) != n {
+		tb.Fatalf("expected %d calls to %s, got %d", n, method, len(calls))
+	}
+}
+
+// AssertSentText fails the test unless the bot sent a message whose text or
+// caption contains substr, and returns the last such message.
+func (s *Server) AssertSentText(tb testing.TB, substr string) *models.Message {
+	tb.Helper()
+	messages := s.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i]
+		if strings.Contains(m.Text, substr) || strings.Contains(m.Caption, substr) {
+			return m
+		}
+	}
+	tb.Fatalf("expected a message containing %q, sent messages:\n%s", substr, describeMessages(messages))
+	return nil
+}
+
+// AssertSentTo fails the test unless the bot sent a message to chatID, and
+// returns the last one.
+func (s *Server) AssertSentTo(tb testing.TB, chatID int64) *models.Message {
+	tb.Helper()
+	messages := s.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Chat.Id == chatID {
+			return messages[i]
+		}
+	}
+	tb.Fatalf("expected a message to chat %d, sent messages:\n%s", chatID, describeMessages(messages))
+	return nil
+}
+
+// AssertButton fails the test unless the message has an inline button with
+// the given text, and returns it.
+func AssertButton(tb testing.TB, message *models.Message, text string) models.InlineKeyboardButton {
+	tb.Helper()
+	if message.ReplyMarkup != nil {
+		for _, row := range message.ReplyMarkup.InlineKeyboard {
+			for _, button := range row {
+				if button.Text == text {
+					return button
+				}
+			}
+		}
+	}
+	tb.Fatalf("expected a button %q on message %d %q", text, message.MessageId, message.Text)
+	return models.InlineKeyboardButton{}
+}
+
+func (s *Server) describeCalls() string {
+	calls := s.Calls()
+	if len(calls) == 0 {
+		return "no calls"
+	}
+	methods := make([]string, len(calls))
+	for i, c := range calls {
+		methods[i] = c.Method
+	}
+	return strings.Join(methods, ", ")
+}
+
+func describeMessages(messages []*models.Message) string {
+	if len(messages) == 0 {
+		return "  (none)"
+	}
+	var sb strings.Builder
+	for _, m := range messages {
+		text := m.Text
+		if text == "" {
+			text = m.Caption
+		}
+		sb.WriteString("  ")
+		sb.WriteString(strings.ReplaceAll(text, "\n", `\n`))
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,196 @@
+package tgxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+)
+
+// Mismatch is a difference between a recorded call and the call made on
+// replay.
+type Mismatch struct {
+	UpdateID int
+	Index    int         // position of the call among those made for the update
+	Want     *tgx.Record // nil when the call wasn't recorded
+	Got      *Call       // nil when the call wasn't made
+	Diff     []string    // differing parameters when both are set
+}
+
+func (m Mismatch) String() string {
+	prefix := fmt.Sprintf("update %d, call %d: ", m.UpdateID, m.Index+1)
+	switch {
+	case m.Got == nil:
+		return prefix + fmt.Sprintf("missing %s %s", m.Want.Method, m.Want.Params)
+	case m.Want == nil:
+		return prefix + fmt.Sprintf("unexpected %s", m.Got.Method)
+	case m.Want.Method != m.Got.Method:
+		return prefix + fmt.Sprintf("want %s, got %s", m.Want.Method, m.Got.Method)
+	default:
+		return prefix + m.Got.Method + "\n    " + strings.Join(m.Diff, "\n    ")
+	}
+}
+
+// Replay feeds the updates of a session written by a tgx.Recorder to the
+// attached bot and compares the calls it makes with the recorded ones. The
+// recorded results are answered to the bot so it sees the same responses as
+// in the original session. Calls made before the first update, such as
+// setWebhook on startup, are not compared.
+//
+// Calls are attributed to the update they follow, so the bot must handle
+// updates synchronously, i.e. without a worker pool, both when recording and
+// replaying.
+func (s *Server) Replay(r io.Reader) ([]Mismatch, error) {
+	records, err := tgx.ReadRecords(r)
+	if err != nil {
+		return nil, fmt.Errorf("tgxtest: failed to read records: %w", err)
+	}
+
+	var mismatches []Mismatch
+	for i := 0; i < len(records); i++ {
+		if records[i].Kind != tgx.RecordUpdate || records[i].Update == nil {
+			continue
+		}
+		update := *records[i].Update
+
+		var want []tgx.Record
+		for i+1 < len(records) && records[i+1].Kind == tgx.RecordCall {
+			i++
+			want = append(want, records[i])
+		}
+
+		s.mu.Lock()
+		s.queued = make(map[string][]Response)
+		s.mu.Unlock()
+		for _, record := range want {
+			if !record.Webhook {
+				s.Queue(record.Method, recordedResponse(record))
+			}
+		}
+
+		before := len(s.Calls())
+		if _, err := s.SendUpdate(&update); err != nil {
+			return mismatches, err
+		}
+		got := s.Calls()[before:]
+
+		mismatches = append(mismatches, compareCalls(update.UpdateId, want, got)...)
+	}
+	return mismatches, nil
+}
+
+// AssertReplay replays a recorded session and fails the test on any
+// mismatch.
+func (s *Server) AssertReplay(tb testing.TB, r io.Reader) {
+	tb.Helper()
+	mismatches, err := s.Replay(r)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if len(mismatches) == 0 {
+		return
+	}
+	lines := make([]string, len(mismatches))
+	for i, m := range mismatches {
+		lines[i] = m.String()
+	}
+	tb.Fatalf("replay differs from recording:\n%s", strings.Join(lines, "\n"))
+}
+
+func recordedResponse(record tgx.Record) Response {
+	if record.Error != nil {
+		return Response{
+			ErrorCode:   record.Error.Code,
+			Description: record.Error.Description,
+			RetryAfter:  record.Error.Parameters.RetryAfter,
+			MigrateTo:   record.Error.Parameters.MigrateToChatId,
+		}
+	}
+	if len(record.Result) == 0 {
+		return OK(true)
+	}
+	return OK(record.Result)
+}
+
+func compareCalls(updateID int, want []tgx.Record, got []Call) []Mismatch {
+	var mismatches []Mismatch
+	for i := 0; i < len(want) || i < len(got); i++ {
+		m := Mismatch{UpdateID: updateID, Index: i}
+		if i < len(want) {
+			m.Want = &want[i]
+		}
+		if i < len(got) {
+			m.Got = &got[i]
+		}
+		if m.Want != nil && m.Got != nil && m.Want.Method == m.Got.Method {
+			if m.Diff = diffParams(m.Want.Params, m.Got); len(m.Diff) == 0 {
+				continue
+			}
+		}
+		mismatches = append(mismatches, m)
+	}
+	return mismatches
+}
+
+// diffParams compares recorded parameters with the ones of a call. Values
+// are compared by their JSON form so JSON and multipart requests compare
+// equal, and uploads are only checked for presence.
+func diffParams(recorded json.RawMessage, call *Call) []string {
+	want := make(map[string]interface{})
+	if len(recorded) > 0 {
+		if err := json.Unmarshal(recorded, &want); err != nil {
+			return []string{fmt.Sprintf("failed to decode recorded params: %v", err)}
+		}
+	}
+
+	keys := make(map[string]bool)
+	for key := range want {
+		keys[key] = true
+	}
+	for key := range call.Params {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var diff []string
+	for _, key := range sorted {
+		wantVal, wantOK := want[key]
+		gotVal, gotOK := call.Params[key]
+		if s, ok := wantVal.(string); ok && strings.HasPrefix(s, "attach://") {
+			if _, uploaded := call.Files[key]; uploaded || gotOK {
+				continue
+			}
+		}
+
+		switch {
+		case !gotOK:
+			diff = append(diff, fmt.Sprintf("%s: want %s, not set", key, canonical(wantVal)))
+		case !wantOK:
+			diff = append(diff, fmt.Sprintf("%s: not recorded, got %s", key, canonical(gotVal)))
+		case canonical(wantVal) != canonical(gotVal):
+			diff = append(diff, fmt.Sprintf("%s: want %s, got %s", key, canonical(wantVal), canonical(gotVal)))
+		}
+	}
+	return diff
+}
+
+// canonical returns the JSON form of a parameter, strings holding JSON such
+// as multipart fields are decoded first.
+func canonical(v interface{}) string {
+	if s, ok := v.(string); ok {
+		var decoded interface{}
+		if json.Unmarshal([]byte(s), &decoded) == nil {
+			v = decoded
+		}
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
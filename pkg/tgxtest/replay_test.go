@@ -0,0 +1,63 @@
+package tgxtest_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func recordSession(t *testing.T, handler tgx.Handler) []byte {
+	t.Helper()
+	bot, srv := newBot(t)
+	bot.OnCommand("start", handler)
+	var buf bytes.Buffer
+	bot.UseRecorder(tgx.NewRecorder(&buf))
+
+	for _, text := range []string{"/start a", "/start b"} {
+		if _, err := srv.SendText(tgxtest.User(1), tgxtest.PrivateChat(1), text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestReplayMatches(t *testing.T) {
+	greet := func(ctx *tgx.Context) error {
+		return ctx.Reply("hello " + strings.Join(ctx.Args, " "))
+	}
+	session := recordSession(t, greet)
+
+	bot, srv := newBot(t)
+	bot.OnCommand("start", greet)
+	srv.AssertReplay(t, bytes.NewReader(session))
+}
+
+func TestReplayReportsMismatches(t *testing.T) {
+	session := recordSession(t, func(ctx *tgx.Context) error {
+		return ctx.Reply("hello " + strings.Join(ctx.Args, " "))
+	})
+
+	bot, srv := newBot(t)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		if ctx.Args[0] == "b" {
+			return nil
+		}
+		return ctx.Reply("hi " + ctx.Args[0])
+	})
+	mismatches, err := srv.Replay(bytes.NewReader(session))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 2 {
+		t.Fatalf("mismatches = %v, want 2", mismatches)
+	}
+	if m := mismatches[0]; m.Got == nil || m.Want == nil || len(m.Diff) == 0 {
+		t.Errorf("first mismatch = %s, want a parameter diff", m)
+	}
+	if m := mismatches[1]; m.Got != nil || m.Want == nil {
+		t.Errorf("second mismatch = %s, want a missing call", m)
+	}
+}
@@ -0,0 +1,404 @@
+package tgxtest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+)
+
+// Matcher checks one aspect of an outgoing call.
+type Matcher struct {
+	desc  string
+	check func(c Call) string // reason the call doesn't match, empty when it does
+}
+
+// Containing matches calls whose text or caption contains substr.
+func Containing(substr string) Matcher {
+	return Matcher{
+		desc: fmt.Sprintf("containing %q", substr),
+		check: func(c Call) string {
+			text := callText(c)
+			if strings.Contains(text, substr) {
+				return ""
+			}
+			return fmt.Sprintf("text %q doesn't contain %q", text, substr)
+		},
+	}
+}
+
+// Text matches calls whose text or caption is exactly text.
+func Text(text string) Matcher {
+	return Matcher{
+		desc: fmt.Sprintf("with text %q", text),
+		check: func(c Call) string {
+			if got := callText(c); got != text {
+				return fmt.Sprintf("text is %q, want %q", got, text)
+			}
+			return ""
+		},
+	}
+}
+
+// WithButton matches calls with an inline keyboard having a button labelled
+// text.
+func WithButton(text string) Matcher {
+	return Matcher{
+		desc: fmt.Sprintf("with button %q", text),
+		check: func(c Call) string {
+			buttons := callButtons(c)
+			for _, b := range buttons {
+				if b.Text == text {
+					return ""
+				}
+			}
+			return fmt.Sprintf("no button %q among %s", text, describeButtons(buttons))
+		},
+	}
+}
+
+// Param matches calls whose parameter key equals value, compared by their
+// JSON form.
+func Param(key string, value interface{}) Matcher {
+	return Matcher{
+		desc: fmt.Sprintf("with %s=%v", key, value),
+		check: func(c Call) string {
+			got, ok := c.Params[key]
+			if !ok {
+				return fmt.Sprintf("%s is not set", key)
+			}
+			if canonical(got) != canonical(value) {
+				return fmt.Sprintf("%s is %s, want %s", key, canonical(got), canonical(value))
+			}
+			return ""
+		},
+	}
+}
+
+// Scenario describes a conversation with a bot as a sequence of user
+// actions and expected calls, and runs it against a fake Bot API server.
+//
+//	tgxtest.NewScenario(t, bot).
+//		As(42).Sends("/start").
+//		ExpectMessage(tgxtest.Containing("Welcome"), tgxtest.WithButton("Settings")).
+//		Clicks("Settings").
+//		ExpectEdit(tgxtest.Containing("Settings")).
+//		Run()
+//
+// Expectations consume the calls made since the previous action in order:
+// each one matches a call made after the call consumed by the expectation
+// before it. Other calls may come in between, ExpectNoMoreCalls reports
+// them. Each expectation waits up to Timeout for its call so bots using a
+// worker pool work as well. Actions fail when the bot doesn't answer the
+// update with 200.
+type Scenario struct {
+	// Timeout bounds how long an expectation waits for its call, defaults
+	// to a second
+	Timeout time.Duration
+
+	tb    testing.TB
+	srv   *Server
+	steps []scenarioStep
+
+	user models.User
+	chat models.Chat
+}
+
+type scenarioStep struct {
+	desc string
+	run  func(r *scenarioRun) string // failure message, empty on success
+}
+
+// scenarioRun is the state of a running scenario.
+type scenarioRun struct {
+	*Scenario
+	lastAction string
+	user       models.User
+	chat       models.Chat
+	consumed   map[int]bool
+	from       int // index of the first call made after the last action
+	next       int // index of the first call the next expectation may match
+}
+
+// NewScenario starts a fake server for bot, it is closed when the test ends.
+func NewScenario(tb testing.TB, bot *tgx.Bot) *Scenario {
+	srv := NewServer()
+	tb.Cleanup(srv.Close)
+	srv.Attach(bot)
+	return &Scenario{
+		Timeout: time.Second,
+		tb:      tb,
+		srv:     srv,
+		user:    User(1),
+		chat:    PrivateChat(1),
+	}
+}
+
+// Server returns the fake server, e.g. to script responses.
+func (s *Scenario) Server() *Server {
+	return s.srv
+}
+
+// As makes the following actions come from the user with the given id in
+// their private chat.
+func (s *Scenario) As(userID int64) *Scenario {
+	return s.AsUser(User(userID), PrivateChat(userID))
+}
+
+// AsUser makes the following actions come from user in chat.
+func (s *Scenario) AsUser(user models.User, chat models.Chat) *Scenario {
+	s.steps = append(s.steps, scenarioStep{
+		desc: fmt.Sprintf("as user %d in chat %d", user.Id, chat.Id),
+		run: func(r *scenarioRun) string {
+			r.user, r.chat = user, chat
+			return ""
+		},
+	})
+	return s
+}
+
+// Sends delivers a text message from the current user.
+func (s *Scenario) Sends(text string) *Scenario {
+	desc := fmt.Sprintf("user sends %q", text)
+	s.steps = append(s.steps, scenarioStep{
+		desc: desc,
+		run: func(r *scenarioRun) string {
+			r.startAction(fmt.Sprintf("user %d sends %q", r.user.Id, text))
+			if _, err := r.srv.SendText(r.user, r.chat, text); err != nil {
+				return err.Error()
+			}
+			return ""
+		},
+	})
+	return s
+}
+
+// Clicks presses the inline button labelled text on the most recent bot
+// message in the current chat showing it.
+func (s *Scenario) Clicks(text string) *Scenario {
+	s.steps = append(s.steps, scenarioStep{
+		desc: fmt.Sprintf("user clicks %q", text),
+		run: func(r *scenarioRun) string {
+			r.startAction(fmt.Sprintf("user %d clicks %q", r.user.Id, text))
+			message, data := r.findButton(text)
+			if message == nil {
+				return fmt.Sprintf("no message in chat %d has a button %q, sent messages:\n%s",
+					r.chat.Id, text, describeMessages(r.srv.Messages()))
+			}
+			if _, err := r.srv.ClickButtonAs(r.user, message, data); err != nil {
+				return err.Error()
+			}
+			return ""
+		},
+	})
+	return s
+}
+
+// Do runs fn as a step, e.g. to script responses between actions.
+func (s *Scenario) Do(desc string, fn func(srv *Server)) *Scenario {
+	s.steps = append(s.steps, scenarioStep{
+		desc: desc,
+		run: func(r *scenarioRun) string {
+			fn(r.srv)
+			return ""
+		},
+	})
+	return s
+}
+
+// Expect expects a call to method matching all matchers.
+func (s *Scenario) Expect(method string, matchers ...Matcher) *Scenario {
+	desc := "expect " + method
+	for _, m := range matchers {
+		desc += " " + m.desc
+	}
+	s.steps = append(s.steps, scenarioStep{
+		desc: desc,
+		run: func(r *scenarioRun) string {
+			return r.expect(method, matchers)
+		},
+	})
+	return s
+}
+
+// ExpectMessage expects a sendMessage call matching all matchers.
+func (s *Scenario) ExpectMessage(matchers ...Matcher) *Scenario {
+	return s.Expect("sendMessage", matchers...)
+}
+
+// ExpectEdit expects an editMessageText call matching all matchers.
+func (s *Scenario) ExpectEdit(matchers ...Matcher) *Scenario {
+	return s.Expect("editMessageText", matchers...)
+}
+
+// ExpectAnswer expects an answerCallbackQuery call matching all matchers.
+func (s *Scenario) ExpectAnswer(matchers ...Matcher) *Scenario {
+	return s.Expect("answerCallbackQuery", matchers...)
+}
+
+// ExpectNoMoreCalls expects every call made since the last action to be
+// consumed by an expectation. It doesn't wait for late calls.
+func (s *Scenario) ExpectNoMoreCalls() *Scenario {
+	s.steps = append(s.steps, scenarioStep{
+		desc: "expect no more calls",
+		run: func(r *scenarioRun) string {
+			var extra []string
+			for i, c := range r.srv.Calls() {
+				if i >= r.from && !r.consumed[i] {
+					extra = append(extra, "    "+describeCall(c))
+				}
+			}
+			if len(extra) == 0 {
+				return ""
+			}
+			return fmt.Sprintf("unexpected calls after %s:\n%s", r.lastAction, strings.Join(extra, "\n"))
+		},
+	})
+	return s
+}
+
+// Run executes the scenario and fails the test at the first step that
+// doesn't hold.
+func (s *Scenario) Run() {
+	s.tb.Helper()
+	r := &scenarioRun{
+		Scenario:   s,
+		lastAction: "the start",
+		user:       s.user,
+		chat:       s.chat,
+		consumed:   make(map[int]bool),
+	}
+	for i, step := range s.steps {
+		if msg := step.run(r); msg != "" {
+			s.tb.Fatalf("scenario step %d failed: %s\n  %s", i+1, step.desc, strings.ReplaceAll(msg, "\n", "\n  "))
+		}
+	}
+}
+
+func (r *scenarioRun) startAction(desc string) {
+	r.lastAction = desc
+	r.from = len(r.srv.Calls())
+	r.next = r.from
+}
+
+func (r *scenarioRun) expect(method string, matchers []Matcher) string {
+	deadline := time.Now().Add(r.Timeout)
+	for {
+		calls := r.srv.Calls()
+		for i := r.next; i < len(calls); i++ {
+			if calls[i].Method != method || !matches(calls[i], matchers) {
+				continue
+			}
+			r.consumed[i] = true
+			r.next = i + 1
+			return ""
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 || !r.srv.WaitForCalls(len(calls)+1, remaining) {
+			return r.describeMismatch(method, matchers, r.srv.Calls())
+		}
+	}
+}
+
+// describeMismatch lists the calls made since the last action along with
+// why each call to method didn't match.
+func (r *scenarioRun) describeMismatch(method string, matchers []Matcher, calls []Call) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "calls after %s:", r.lastAction)
+	if r.from >= len(calls) {
+		sb.WriteString("\n  (none)")
+	}
+	for i := r.from; i < len(calls); i++ {
+		c := calls[i]
+		mark := " "
+		if r.consumed[i] {
+			mark = "✓"
+		}
+		fmt.Fprintf(&sb, "\n%s %s", mark, describeCall(c))
+		if r.consumed[i] || c.Method != method {
+			continue
+		}
+		if i < r.next {
+			sb.WriteString("\n    ✗ made before the previously expected call")
+			continue
+		}
+		for _, m := range matchers {
+			if reason := m.check(c); reason != "" {
+				fmt.Fprintf(&sb, "\n    ✗ %s", reason)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func (r *scenarioRun) findButton(text string) (*models.Message, string) {
+	messages := r.srv.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i]
+		if m.Chat.Id != r.chat.Id || m.ReplyMarkup == nil {
+			continue
+		}
+		for _, row := range m.ReplyMarkup.InlineKeyboard {
+			for _, button := range row {
+				if button.Text == text {
+					return m, button.CallbackData
+				}
+			}
+		}
+	}
+	return nil, ""
+}
+
+func matches(c Call, matchers []Matcher) bool {
+	for _, m := range matchers {
+		if m.check(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func callText(c Call) string {
+	if text := c.String("text"); text != "" {
+		return text
+	}
+	return c.String("caption")
+}
+
+func callButtons(c Call) []models.InlineKeyboardButton {
+	var markup models.InlineKeyboardMarkup
+	if c.Decode("reply_markup", &markup) != nil {
+		return nil
+	}
+	var buttons []models.InlineKeyboardButton
+	for _, row := range markup.InlineKeyboard {
+		buttons = append(buttons, row...)
+	}
+	return buttons
+}
+
+func describeButtons(buttons []models.InlineKeyboardButton) string {
+	labels := make([]string, len(buttons))
+	for i, b := range buttons {
+		labels[i] = fmt.Sprintf("%q", b.Text)
+	}
+	return "[" + strings.Join(labels, ", ") + "]"
+}
+
+func describeCall(c Call) string {
+	desc := c.Method
+	if chatID := c.Int("chat_id"); chatID != 0 {
+		desc += fmt.Sprintf(" chat=%d", chatID)
+	}
+	if text := callText(c); text != "" {
+		desc += fmt.Sprintf(" text=%q", text)
+	}
+	if buttons := callButtons(c); len(buttons) > 0 {
+		desc += " buttons=" + describeButtons(buttons)
+	}
+	return desc
+}
@@ -0,0 +1,108 @@
+package tgxtest_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+// failTB records the failure of a scenario instead of failing the test.
+type failTB struct {
+	testing.TB
+	failure string
+}
+
+func (f *failTB) Helper() {}
+
+func (f *failTB) Fatalf(format string, args ...interface{}) {
+	f.failure = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// runScenario runs the scenario built by build and returns its failure
+// message, empty when it passed.
+func runScenario(t *testing.T, bot *tgx.Bot, build func(s *tgxtest.Scenario) *tgxtest.Scenario) string {
+	tb := &failTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		build(tgxtest.NewScenario(tb, bot)).Run()
+	}()
+	<-done
+	return tb.failure
+}
+
+func menuBot() *tgx.Bot {
+	bot := tgx.NewBot(tgxtest.Token, "", nil)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		if err := ctx.Reply("one"); err != nil {
+			return err
+		}
+		return ctx.ReplyWithInlineKeyboard("two", [][]models.InlineKeyboardButton{
+			{{Text: "Settings", CallbackData: "settings"}},
+		})
+	})
+	bot.OnCallback("settings", func(ctx *tgx.CallbackContext) error {
+		return ctx.EditMessage("Settings", nil)
+	})
+	return bot
+}
+
+func TestScenarioPasses(t *testing.T) {
+	tgxtest.NewScenario(t, menuBot()).
+		As(42).Sends("/start").
+		ExpectMessage(tgxtest.Text("one")).
+		ExpectMessage(tgxtest.Containing("tw"), tgxtest.WithButton("Settings")).
+		ExpectNoMoreCalls().
+		Clicks("Settings").
+		ExpectEdit(tgxtest.Text("Settings")).
+		Run()
+}
+
+func TestScenarioEnforcesOrder(t *testing.T) {
+	failure := runScenario(t, menuBot(), func(s *tgxtest.Scenario) *tgxtest.Scenario {
+		return s.Sends("/start").
+			ExpectMessage(tgxtest.Text("two")).
+			ExpectMessage(tgxtest.Text("one"))
+	})
+	if !strings.Contains(failure, "step 3") || !strings.Contains(failure, "before the previously expected call") {
+		t.Errorf("failure = %q, want step 3 to fail on order", failure)
+	}
+}
+
+func TestScenarioUnexpectedCalls(t *testing.T) {
+	failure := runScenario(t, menuBot(), func(s *tgxtest.Scenario) *tgxtest.Scenario {
+		return s.Sends("/start").
+			ExpectMessage(tgxtest.Text("two")).
+			ExpectNoMoreCalls()
+	})
+	if !strings.Contains(failure, "unexpected calls") || !strings.Contains(failure, `"one"`) {
+		t.Errorf("failure = %q", failure)
+	}
+}
+
+// An update the bot rejects fails the action.
+func TestScenarioRejectedUpdate(t *testing.T) {
+	bot := menuBot()
+	bot.UseSecretToken("s3cret")
+	failure := runScenario(t, bot, func(s *tgxtest.Scenario) *tgxtest.Scenario {
+		return s.Sends("/start")
+	})
+	if !strings.Contains(failure, "status 401") {
+		t.Errorf("failure = %q, want the 401 reported", failure)
+	}
+}
+
+func TestScenarioMissingButton(t *testing.T) {
+	failure := runScenario(t, menuBot(), func(s *tgxtest.Scenario) *tgxtest.Scenario {
+		return s.Sends("/start").Clicks("Help")
+	})
+	if !strings.Contains(failure, `button "Help"`) {
+		t.Errorf("failure = %q", failure)
+	}
+}
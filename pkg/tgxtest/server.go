@@ -0,0 +1,457 @@
+// Package tgxtest provides an in-process fake of the Telegram Bot API for
+// testing bots without talking to Telegram.
+//
+//	srv := tgxtest.NewServer()
+//	defer srv.Close()
+//
+//	bot := tgx.NewBot(tgxtest.Token, "", nil)
+//	srv.Attach(bot)
+//	bot.OnCommand("start", start)
+//
+//	srv.SendText(tgxtest.User(42), tgxtest.PrivateChat(42), "/start")
+//	srv.AssertSentText(t, "Welcome")
+package tgxtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+)
+
+// Token is a well formed bot token accepted by the fake server.
+const Token = "123456:TEST-TOKEN"
+
+// Call is one Bot API method call made by the bot.
+type Call struct {
+	Method string
+	// Params holds the decoded parameters. JSON requests keep their JSON
+	// types, multipart fields are strings.
+	Params map[string]interface{}
+	// Files holds the contents of uploaded files by form field name
+	Files map[string][]byte
+	// Webhook is set for calls returned in the body of a webhook response
+	Webhook bool
+}
+
+// String returns the parameter as a string, JSON values are re-encoded.
+func (c Call) String(key string) string {
+	switch v := c.Params[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// Int returns the parameter as an integer, 0 when missing or not a number.
+func (c Call) Int(key string) int64 {
+	switch v := c.Params[key].(type) {
+	case float64:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// Decode decodes the parameter into v, which works for both JSON and
+// multipart encoded values.
+func (c Call) Decode(key string, v interface{}) error {
+	raw, ok := c.Params[key]
+	if !ok {
+		return fmt.Errorf("parameter %q not set", key)
+	}
+	if s, ok := raw.(string); ok && json.Valid([]byte(s)) {
+		return json.Unmarshal([]byte(s), v)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Response is what the fake server answers to a call.
+type Response struct {
+	Result      interface{}
+	ErrorCode   int
+	Description string
+	RetryAfter  int
+	MigrateTo   int64
+}
+
+// OK answers successfully with the given result.
+func OK(result interface{}) Response {
+	return Response{Result: result}
+}
+
+// Error answers with a Bot API error.
+func Error(code int, description string) Response {
+	return Response{ErrorCode: code, Description: description}
+}
+
+// TooManyRequests answers like a rate limited call.
+func TooManyRequests(retryAfter int) Response {
+	return Response{
+		ErrorCode:   http.StatusTooManyRequests,
+		Description: fmt.Sprintf("Too Many Requests: retry after %d", retryAfter),
+		RetryAfter:  retryAfter,
+	}
+}
+
+// Forbidden answers like a call to a user who blocked the bot.
+func Forbidden() Response {
+	return Error(http.StatusForbidden, "Forbidden: bot was blocked by the user")
+}
+
+// BadRequest answers with a 400 and the given description.
+func BadRequest(description string) Response {
+	return Error(http.StatusBadRequest, "Bad Request: "+description)
+}
+
+// Server is a fake Bot API server. It records every call, answers with
+// scripted responses when there are any and with plausible results
+// otherwise, and keeps track of the messages the bot sent.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	secretToken   string
+	direct        bool
+	bot           *tgx.Bot
+	calls         []Call
+	queued        map[string][]Response
+	handlers      map[string]func(Call) Response
+	messages      []*models.Message
+	lastUser      map[int64]models.User
+	nextMessageID int64
+	nextUpdateID  int
+	notify        chan struct{}
+}
+
+// NewServer starts a fake Bot API server, it must be closed after use.
+func NewServer() *Server {
+	s := &Server{
+		queued:   make(map[string][]Response),
+		handlers: make(map[string]func(Call) Response),
+		lastUser: make(map[int64]models.User),
+		notify:   make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Attach points the bot at the fake server and makes it the target of
+// injected updates.
+func (s *Server) Attach(bot *tgx.Bot) {
+	bot.UseAPIServer(s.URL, false)
+	s.mu.Lock()
+	s.bot = bot
+	s.mu.Unlock()
+}
+
+// SetSecretToken sets the secret sent with injected updates, for bots using
+// UseSecretToken only. It is picked up from setWebhook calls otherwise.
+func (s *Server) SetSecretToken(secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secretToken = secret
+}
+
+// SetDirect delivers updates through Bot.ProcessUpdate instead of
+// HandleWebhook, bypassing the secret check, deduplication and the worker
+// pool.
+func (s *Server) SetDirect(direct bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.direct = direct
+}
+
+// Queue scripts the next responses to method, they are used once each and
+// in order before falling back to the default answer.
+func (s *Server) Queue(method string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued[method] = append(s.queued[method], responses...)
+}
+
+// Handle answers every call to method with handler, queued responses still
+// take precedence. A nil handler restores the default answer.
+func (s *Server) Handle(method string, handler func(Call) Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if handler == nil {
+		delete(s.handlers, method)
+		return
+	}
+	s.handlers[method] = handler
+}
+
+// Calls returns all calls recorded so far.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the recorded calls to method.
+func (s *Server) CallsTo(method string) []Call {
+	var calls []Call
+	for _, c := range s.Calls() {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// LastCall returns the most recent call to method.
+func (s *Server) LastCall(method string) (Call, bool) {
+	calls := s.CallsTo(method)
+	if len(calls) == 0 {
+		return Call{}, false
+	}
+	return calls[len(calls)-1], true
+}
+
+// Messages returns the messages the bot sent, with edits applied.
+func (s *Server) Messages() []*models.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := make([]*models.Message, len(s.messages))
+	for i, m := range s.messages {
+		copied := *m
+		messages[i] = &copied
+	}
+	return messages
+}
+
+// Reset forgets recorded calls, sent messages and scripted responses.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+	s.messages = nil
+	s.queued = make(map[string][]Response)
+	s.handlers = make(map[string]func(Call) Response)
+}
+
+// WaitForCalls waits until at least n calls were recorded, for bots that
+// handle updates asynchronously on a worker pool.
+func (s *Server) WaitForCalls(n int, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		count, notify := len(s.calls), s.notify
+		s.mu.Unlock()
+		if count >= n {
+			return true
+		}
+		select {
+		case <-notify:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// /bot<token>/<method>
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	token, method, ok := strings.Cut(strings.TrimPrefix(path, "bot"), "/")
+	if !ok || !strings.HasPrefix(path, "bot") {
+		http.NotFound(w, r)
+		return
+	}
+	if !strings.Contains(token, ":") {
+		writeResponse(w, Error(http.StatusUnauthorized, "Unauthorized"))
+		return
+	}
+
+	call, err := decodeCall(method, r)
+	if err != nil {
+		writeResponse(w, BadRequest(err.Error()))
+		return
+	}
+	writeResponse(w, s.record(call))
+}
+
+// record stores the call and works out its response. Handlers run without
+// the lock held so they can use the server.
+func (s *Server) record(call Call) Response {
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	if call.Method == "setWebhook" && call.String("secret_token") != "" {
+		s.secretToken = call.String("secret_token")
+	}
+	close(s.notify)
+	s.notify = make(chan struct{})
+
+	// calls in webhook responses get no answer, they must not use up
+	// scripted responses
+	if call.Webhook {
+		defer s.mu.Unlock()
+		return s.defaultResponse(call)
+	}
+	if queued := s.queued[call.Method]; len(queued) > 0 {
+		s.queued[call.Method] = queued[1:]
+		s.mu.Unlock()
+		return queued[0]
+	}
+	if handler, ok := s.handlers[call.Method]; ok {
+		s.mu.Unlock()
+		return handler(call)
+	}
+	defer s.mu.Unlock()
+	return s.defaultResponse(call)
+}
+
+func (s *Server) defaultResponse(call Call) Response {
+	switch call.Method {
+	case "getMe":
+		return OK(models.User{Id: 123456, FirstName: "Test Bot", Username: "test_bot"})
+	case "getFile":
+		id := call.String("file_id")
+		return OK(models.File{FileId: id, FileUniqueId: id, FilePath: "files/" + id})
+	case "getWebhookInfo":
+		return OK(tgx.WebhookInfo{})
+	case "sendMessage", "sendPhoto", "sendAudio", "sendDocument", "sendVideo",
+		"sendAnimation", "sendVoice", "sendVideoNote", "sendSticker":
+		return OK(s.storeMessage(call))
+	case "sendMediaGroup":
+		return OK([]*models.Message{s.storeMessage(call)})
+	case "editMessageText", "editMessageCaption", "editMessageReplyMarkup":
+		if m := s.editMessage(call); m != nil {
+			return OK(m)
+		}
+		return BadRequest("message to edit not found")
+	default:
+		return OK(true)
+	}
+}
+
+func (s *Server) storeMessage(call Call) *models.Message {
+	s.nextMessageID++
+	m := &models.Message{
+		MessageId: s.nextMessageID,
+		From:      models.User{Id: 123456, FirstName: "Test Bot", Username: "test_bot"},
+		Chat:      models.Chat{Id: call.Int("chat_id")},
+		Text:      call.String("text"),
+		Caption:   call.String("caption"),
+	}
+	call.Decode("entities", &m.Entities)
+	call.Decode("caption_entities", &m.CaptionEntities)
+	call.Decode("reply_markup", &m.ReplyMarkup)
+	s.messages = append(s.messages, m)
+
+	copied := *m
+	return &copied
+}
+
+func (s *Server) editMessage(call Call) *models.Message {
+	chatID, messageID := call.Int("chat_id"), call.Int("message_id")
+	for _, m := range s.messages {
+		if m.Chat.Id != chatID || m.MessageId != messageID {
+			continue
+		}
+		switch call.Method {
+		case "editMessageText":
+			m.Text = call.String("text")
+			m.Entities = nil
+			call.Decode("entities", &m.Entities)
+		case "editMessageCaption":
+			m.Caption = call.String("caption")
+			m.CaptionEntities = nil
+			call.Decode("caption_entities", &m.CaptionEntities)
+		}
+		m.ReplyMarkup = nil
+		call.Decode("reply_markup", &m.ReplyMarkup)
+
+		copied := *m
+		return &copied
+	}
+	return nil
+}
+
+func decodeCall(method string, r *http.Request) (Call, error) {
+	call := Call{Method: method, Params: make(map[string]interface{})}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return call, err
+		}
+		for key, values := range r.MultipartForm.Value {
+			call.Params[key] = values[0]
+		}
+		call.Files = make(map[string][]byte)
+		for key, headers := range r.MultipartForm.File {
+			data, err := readFormFile(headers[0])
+			if err != nil {
+				return call, err
+			}
+			call.Files[key] = data
+		}
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&call.Params); err != nil {
+			return call, err
+		}
+	}
+	if call.Params == nil {
+		call.Params = make(map[string]interface{})
+	}
+	return call, nil
+}
+
+func readFormFile(header *multipart.FileHeader) ([]byte, error) {
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func writeResponse(w http.ResponseWriter, resp Response) {
+	body := map[string]interface{}{"ok": resp.ErrorCode == 0}
+	if resp.ErrorCode == 0 {
+		body["result"] = resp.Result
+	} else {
+		body["error_code"] = resp.ErrorCode
+		body["description"] = resp.Description
+		params := map[string]interface{}{}
+		if resp.RetryAfter != 0 {
+			params["retry_after"] = resp.RetryAfter
+		}
+		if resp.MigrateTo != 0 {
+			params["migrate_to_chat_id"] = resp.MigrateTo
+		}
+		if len(params) > 0 {
+			body["parameters"] = params
+		}
+	}
+
+	status := http.StatusOK
+	if resp.ErrorCode != 0 {
+		status = resp.ErrorCode
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
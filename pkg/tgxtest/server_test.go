@@ -0,0 +1,128 @@
+package tgxtest_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func newBot(t *testing.T) (*tgx.Bot, *tgxtest.Server) {
+	t.Helper()
+	srv := tgxtest.NewServer()
+	t.Cleanup(srv.Close)
+	bot := tgx.NewBot(tgxtest.Token, "", nil)
+	srv.Attach(bot)
+	return bot, srv
+}
+
+func TestSendTextAndReply(t *testing.T) {
+	bot, srv := newBot(t)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		return ctx.ReplyWithInlineKeyboard("Welcome "+ctx.Username, [][]models.InlineKeyboardButton{
+			{{Text: "Go", CallbackData: "go"}},
+		})
+	})
+	bot.OnCallback("go", func(ctx *tgx.CallbackContext) error {
+		return ctx.EditMessage("Gone", nil)
+	})
+
+	if _, err := srv.SendText(tgxtest.User(42), tgxtest.PrivateChat(42), "/start"); err != nil {
+		t.Fatal(err)
+	}
+	message := srv.AssertSentText(t, "Welcome user42")
+	tgxtest.AssertButton(t, message, "Go")
+	srv.AssertSentTo(t, 42)
+
+	if _, err := srv.ClickButton("go"); err != nil {
+		t.Fatal(err)
+	}
+	srv.AssertCalled(t, "editMessageText")
+	if messages := srv.Messages(); len(messages) != 1 || messages[0].Text != "Gone" {
+		t.Errorf("messages = %+v, want the edited message", messages)
+	}
+}
+
+func TestScriptedResponses(t *testing.T) {
+	bot, srv := newBot(t)
+	srv.Queue("sendMessage", tgxtest.Forbidden(), tgxtest.TooManyRequests(3))
+
+	if err := bot.SendMessage(1, "a"); !tgx.IsAPIError(err, http.StatusForbidden) {
+		t.Errorf("first: err = %v, want 403", err)
+	}
+	if wait, ok := tgx.RetryAfter(bot.SendMessage(1, "b")); !ok || wait.Seconds() != 3 {
+		t.Errorf("second: retry after = %v, %v", wait, ok)
+	}
+	if err := bot.SendMessage(1, "c"); err != nil {
+		t.Errorf("third: err = %v, want the default answer", err)
+	}
+	srv.AssertCallCount(t, "sendMessage", 3)
+}
+
+// Handlers can use the server, e.g. to look at earlier calls.
+func TestHandlerUsesServer(t *testing.T) {
+	bot, srv := newBot(t)
+	srv.Handle("sendMessage", func(c tgxtest.Call) tgxtest.Response {
+		if n := len(srv.CallsTo("sendMessage")); n > 1 {
+			return tgxtest.BadRequest("only one message allowed")
+		}
+		return tgxtest.OK(models.Message{MessageId: 1})
+	})
+
+	if err := bot.SendMessage(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	var botErr *tgx.BotError
+	if err := bot.SendMessage(1, "b"); !errors.As(err, &botErr) || botErr.Code != http.StatusBadRequest {
+		t.Errorf("err = %v, want 400", err)
+	}
+
+	srv.Handle("sendMessage", nil)
+	if err := bot.SendMessage(1, "c"); err != nil {
+		t.Errorf("err = %v after removing the handler", err)
+	}
+}
+
+func TestSecretToken(t *testing.T) {
+	bot, srv := newBot(t)
+	bot.UseSecretToken("s3cret")
+
+	if status, err := srv.SendUpdate(&models.Update{Message: &models.Message{Text: "hi"}}); err != nil || status != http.StatusUnauthorized {
+		t.Errorf("without secret: status = %d, %v", status, err)
+	}
+	srv.SetSecretToken("s3cret")
+	if status, err := srv.SendUpdate(&models.Update{Message: &models.Message{Text: "hi"}}); err != nil || status != http.StatusOK {
+		t.Errorf("with secret: status = %d, %v", status, err)
+	}
+
+	// picked up from setWebhook
+	if err := bot.SetWebhookWithOpts(&tgx.SetWebhookRequest{URL: "https://example.com", SecretToken: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := srv.SendUpdate(&models.Update{Message: &models.Message{Text: "hi"}}); status != http.StatusOK {
+		t.Errorf("after setWebhook: status = %d", status)
+	}
+}
+
+func TestUploadsRecorded(t *testing.T) {
+	bot, srv := newBot(t)
+	req := &tgx.SendPhotoRequest{Photo: tgx.FileFromBytes("cat.jpg", []byte("meow"))}
+	req.ChatId = 7
+	req.Caption = "cat"
+	if err := bot.SendPhoto(req); err != nil {
+		t.Fatal(err)
+	}
+	call := srv.AssertCalled(t, "sendPhoto")
+	if string(call.Files["photo"]) != "meow" || call.Int("chat_id") != 7 {
+		t.Errorf("call = %+v", call)
+	}
+	srv.AssertSentText(t, "cat")
+
+	srv.Reset()
+	if len(srv.Calls()) != 0 || len(srv.Messages()) != 0 {
+		t.Error("Reset kept calls or messages")
+	}
+}
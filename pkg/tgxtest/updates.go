@@ -0,0 +1,185 @@
+package tgxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// User returns a test user with the given id.
+func User(id int64) models.User {
+	return models.User{
+		Id:        id,
+		FirstName: fmt.Sprintf("User %d", id),
+		Username:  fmt.Sprintf("user%d", id),
+	}
+}
+
+// PrivateChat returns the private chat with the user of the given id.
+func PrivateChat(userID int64) models.Chat {
+	return models.Chat{Id: userID, Type: "private"}
+}
+
+// GroupChat returns a supergroup with the given id.
+func GroupChat(id int64) models.Chat {
+	return models.Chat{Id: id, Type: "supergroup"}
+}
+
+// SendUpdate delivers the update to the attached bot through HandleWebhook
+// and returns the HTTP status it answered with. A method call returned in
+// the webhook response is recorded like any other call. With SetDirect the
+// update goes to Bot.ProcessUpdate and the status is always 200.
+func (s *Server) SendUpdate(update *models.Update) (int, error) {
+	s.mu.Lock()
+	bot := s.bot
+	if update.UpdateId == 0 {
+		s.nextUpdateID++
+		update.UpdateId = s.nextUpdateID
+	}
+	secret, direct := s.secretToken, s.direct
+	s.mu.Unlock()
+
+	if bot == nil {
+		return 0, fmt.Errorf("tgxtest: no bot attached")
+	}
+	if direct {
+		bot.ProcessUpdate(update)
+		return http.StatusOK, nil
+	}
+
+	body, err := json.Marshal(update)
+	if err != nil {
+		return 0, err
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+	}
+
+	rec := httptest.NewRecorder()
+	bot.HandleWebhook(rec, req)
+
+	if rec.Body.Len() > 0 && strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		var params map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &params); err == nil {
+			if method, ok := params["method"].(string); ok {
+				delete(params, "method")
+				s.record(Call{Method: method, Params: params, Webhook: true})
+			}
+		}
+	}
+	return rec.Code, nil
+}
+
+// SendMessage delivers a message from message.From, MessageId is filled in
+// when unset. It fails when the bot doesn't answer with 200.
+func (s *Server) SendMessage(message *models.Message) (*models.Message, error) {
+	s.mu.Lock()
+	if message.MessageId == 0 {
+		s.nextMessageID++
+		message.MessageId = s.nextMessageID
+	}
+	s.lastUser[message.Chat.Id] = message.From
+	s.mu.Unlock()
+
+	if err := s.deliver(&models.Update{Message: message}); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// SendText delivers a text message from user in chat. A leading /command is
+// marked with a bot_command entity like Telegram does.
+func (s *Server) SendText(user models.User, chat models.Chat, text string) (*models.Message, error) {
+	message := &models.Message{
+		From: user,
+		Chat: chat,
+		Text: text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		message.Entities = []models.MessageEntity{{
+			Type:   models.EntityBotCommand,
+			Offset: 0,
+			Length: len(utf16.Encode([]rune(command))),
+		}}
+	}
+	return s.SendMessage(message)
+}
+
+// ClickButton presses the inline button with the given callback data on the
+// most recent bot message showing it, as the user who last wrote in that
+// chat.
+func (s *Server) ClickButton(data string) (*models.CallbackQuery, error) {
+	s.mu.Lock()
+	var message *models.Message
+	for i := len(s.messages) - 1; i >= 0 && message == nil; i-- {
+		if hasButton(s.messages[i], data) {
+			copied := *s.messages[i]
+			message = &copied
+		}
+	}
+	var user models.User
+	if message != nil {
+		var ok bool
+		if user, ok = s.lastUser[message.Chat.Id]; !ok {
+			user = User(message.Chat.Id)
+		}
+	}
+	s.mu.Unlock()
+
+	if message == nil {
+		return nil, fmt.Errorf("tgxtest: no message with a button for callback data %q", data)
+	}
+	return s.ClickButtonAs(user, message, data)
+}
+
+// ClickButtonAs presses a button with the given callback data on message as
+// user. It fails when the bot doesn't answer with 200.
+func (s *Server) ClickButtonAs(user models.User, message *models.Message, data string) (*models.CallbackQuery, error) {
+	copied := *message
+	query := &models.CallbackQuery{
+		ID:      fmt.Sprintf("%d", time.Now().UnixNano()),
+		From:    user,
+		Message: &copied,
+		Data:    data,
+	}
+	if err := s.deliver(&models.Update{CallbackQuery: query}); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// deliver sends the update and turns a status other than 200 into an error.
+func (s *Server) deliver(update *models.Update) error {
+	status, err := s.SendUpdate(update)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("tgxtest: bot answered update %d with status %d", update.UpdateId, status)
+	}
+	return nil
+}
+
+func hasButton(message *models.Message, data string) bool {
+	if message.ReplyMarkup == nil {
+		return false
+	}
+	for _, row := range message.ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			if button.CallbackData == data {
+				return true
+			}
+		}
+	}
+	return false
+}
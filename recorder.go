@@ -0,0 +1,129 @@
+package tgx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// Kinds of recorded entries
+const (
+	RecordUpdate = "update"
+	RecordCall   = "call"
+)
+
+// Record is one line of a recorded session, either an update received by
+// the bot or an API call it made.
+type Record struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+
+	Update *models.Update `json:"update,omitempty"`
+
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *APIError       `json:"error,omitempty"`
+	// Webhook is set for calls returned in the webhook response
+	Webhook bool `json:"webhook,omitempty"`
+}
+
+// Recorder writes the updates a bot receives and the API calls it makes as
+// JSON lines. The bot token is redacted from every line.
+type Recorder struct {
+	mu    sync.Mutex
+	w     io.Writer
+	token []byte
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// UseRecorder records the bot's session with r, nil stops recording.
+func (b *Bot) UseRecorder(r *Recorder) {
+	if r != nil {
+		r.mu.Lock()
+		r.token = []byte(b.token)
+		r.mu.Unlock()
+	}
+	b.recorder = r
+}
+
+// ReadRecords reads a session written by a Recorder.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	dec := json.NewDecoder(r)
+	for {
+		var record Record
+		if err := dec.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+func (r *Recorder) write(record *Record) error {
+	record.Time = time.Now()
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.token) > 0 {
+		line = bytes.ReplaceAll(line, r.token, []byte("<redacted>"))
+	}
+	_, err = r.w.Write(append(line, '\n'))
+	return err
+}
+
+func (b *Bot) recordUpdate(update *models.Update) {
+	if b.recorder == nil {
+		return
+	}
+	if err := b.recorder.write(&Record{Kind: RecordUpdate, Update: update}); err != nil {
+		b.logger.Error("Failed to record update", "update_id", update.UpdateId, "error", err)
+	}
+}
+
+func (b *Bot) recordCall(method string, params map[string]interface{}, result json.RawMessage, callErr error, webhook bool) {
+	if b.recorder == nil {
+		return
+	}
+
+	record := &Record{
+		Kind:    RecordCall,
+		Method:  method,
+		Result:  result,
+		Webhook: webhook,
+	}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			b.logger.Error("Failed to record API call", "method", method, "error", err)
+			return
+		}
+		record.Params = data
+	}
+	if callErr != nil {
+		var apiErr *APIError
+		if !errors.As(callErr, &apiErr) {
+			apiErr = &APIError{Description: callErr.Error()}
+		}
+		record.Error = apiErr
+	}
+
+	if err := b.recorder.write(record); err != nil {
+		b.logger.Error("Failed to record API call", "method", method, "error", err)
+	}
+}
@@ -0,0 +1,56 @@
+package tgx_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func TestRecorder(t *testing.T) {
+	bot, srv := newTestBot(t)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		return ctx.Reply("hello")
+	})
+	var buf bytes.Buffer
+	bot.UseRecorder(tgx.NewRecorder(&buf))
+
+	if _, err := srv.SendText(tgxtest.User(1), tgxtest.PrivateChat(1), "/start"); err != nil {
+		t.Fatal(err)
+	}
+	srv.Queue("sendMessage", tgxtest.Forbidden())
+	bot.SendMessage(1, "blocked")
+
+	if strings.Contains(buf.String(), "123:test") {
+		t.Error("recording contains the bot token")
+	}
+	records, err := tgx.ReadRecords(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	if r := records[0]; r.Kind != tgx.RecordUpdate || r.Update.Message.Text != "/start" {
+		t.Errorf("records[0] = %+v", r)
+	}
+	if r := records[1]; r.Kind != tgx.RecordCall || r.Method != "sendMessage" || !bytes.Contains(r.Params, []byte(`"hello"`)) || len(r.Result) == 0 {
+		t.Errorf("records[1] = %+v", r)
+	}
+	if r := records[2]; r.Error == nil || r.Error.Code != 403 {
+		t.Errorf("records[2] = %+v, want the 403 error", r)
+	}
+	for _, r := range records {
+		if r.Time.IsZero() {
+			t.Errorf("%s record has no time", r.Kind)
+		}
+	}
+
+	bot.UseRecorder(nil)
+	bot.SendMessage(1, "not recorded")
+	if buf.Len() != 0 {
+		t.Errorf("recorded after UseRecorder(nil): %s", buf.String())
+	}
+}
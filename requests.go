@@ -10,11 +10,12 @@ const (
 )
 
 type SendMessageRequest struct {
-	ChatId      int64       `json:"chat_id"`              // Required
-	Text        string      `json:"text"`                 // Required
-	ParseMode   ParseMode   `json:"parse_mode,omitempty"` // MarkdownV2 || HTML
-	ReplyMarkup ReplyMarkup `json:"reply_markup,omitempty"`
-	ReplyParams *ReplyParam `json:"reply_paramaters,omitempty"`
+	ChatId      int64                  `json:"chat_id"`              // Required
+	Text        string                 `json:"text"`                 // Required
+	ParseMode   ParseMode              `json:"parse_mode,omitempty"` // MarkdownV2 || HTML
+	Entities    []models.MessageEntity `json:"entities,omitempty"`   // instead of ParseMode
+	ReplyMarkup ReplyMarkup            `json:"reply_markup,omitempty"`
+	ReplyParams *ReplyParam            `json:"reply_paramaters,omitempty"`
 }
 
 type ReplyParam struct {
@@ -73,75 +74,75 @@ type BaseMediaRequest struct {
 	DisableNotification bool        `json:"disable_notification,omitempty"`
 	ProtectContent      bool        `json:"protect_content,omitempty"`
 	AllowPaidBroadCast  bool        `json:"allow_paid_broadcast,omitempty"`
+	// SplitCaption sends the part of a caption longer than
+	// format.MaxCaptionLength as follow-up messages instead of failing
+	SplitCaption bool `json:"-"`
 }
 
 type SendPhotoRequest struct {
 	BaseMediaRequest
-	Photo                 string `json:"photo"` // Required: file_path or file_id
-	ShowCaptionAboveMedia bool   `json:"show_caption_above_media,omitempty"`
-	HasSpoiler            bool   `json:"has_spoiler,omitempty"`
+	Photo                 *InputFile `json:"photo"` // Required
+	ShowCaptionAboveMedia bool       `json:"show_caption_above_media,omitempty"`
+	HasSpoiler            bool       `json:"has_spoiler,omitempty"`
 }
 
 type SendAudioRequest struct {
 	BaseMediaRequest
-	Audio     string `json:"audio"`               // Required: file_path or file_id
-	Duration  int64  `json:"duration,omitempty"`  // Optional: duration in seconds
-	Performer string `json:"performer,omitempty"` // Optional
-	Title     string `json:"title,omitempty"`     // Optional
+	Audio     *InputFile `json:"audio"`               // Required
+	Thumbnail *InputFile `json:"thumbnail,omitempty"` // Optional: upload only
+	Duration  int64      `json:"duration,omitempty"`  // Optional: duration in seconds
+	Performer string     `json:"performer,omitempty"` // Optional
+	Title     string     `json:"title,omitempty"`     // Optional
 }
 
 type SendVideoRequest struct {
 	BaseMediaRequest
-	Video                 string `json:"video"`              // Required: file_path or file_id
-	Duration              int64  `json:"duration,omitempty"` // Optional: duration in seconds
-	Width                 int64  `json:"width,omitempty"`    // Optional
-	Height                int64  `json:"height,omitempty"`   // Optional
-	HasSpoiler            bool   `json:"has_spoiler,omitempty"`
-	ShowCaptionAboveMedia bool   `json:"show_caption_above_media,omitempty"`
-	SupportsStreaming     bool   `json:"supports_streaming,omitempty"` // Optional
+	Video                 *InputFile `json:"video"`               // Required
+	Thumbnail             *InputFile `json:"thumbnail,omitempty"` // Optional: upload only
+	Duration              int64      `json:"duration,omitempty"`  // Optional: duration in seconds
+	Width                 int64      `json:"width,omitempty"`     // Optional
+	Height                int64      `json:"height,omitempty"`    // Optional
+	HasSpoiler            bool       `json:"has_spoiler,omitempty"`
+	ShowCaptionAboveMedia bool       `json:"show_caption_above_media,omitempty"`
+	SupportsStreaming     bool       `json:"supports_streaming,omitempty"` // Optional
 }
 
 type SendDocumentRequest struct {
 	BaseMediaRequest
-	Document                    string `json:"document"`                       // Required: file_path or file_id
-	DisableContentTypeDetection bool   `json:"disable_content_type_detection"` // Optional
+	Document                    *InputFile `json:"document"`                       // Required
+	Thumbnail                   *InputFile `json:"thumbnail,omitempty"`            // Optional: upload only
+	DisableContentTypeDetection bool       `json:"disable_content_type_detection"` // Optional
 }
 
 type SendAnimationRequest struct {
 	BaseMediaRequest
-	Animation             string `json:"animation"`          // Required: file_path or file_id
-	Duration              int64  `json:"duration,omitempty"` // Optional: duration in seconds
-	Width                 int64  `json:"width,omitempty"`    // Optional
-	Height                int64  `json:"height,omitempty"`   // Optional
-	ShowCaptionAboveMedia bool   `json:"show_caption_above_media,omitempty"`
-	HasSpoiler            bool   `json:"has_spoiler,omitempty"`
+	Animation             *InputFile `json:"animation"`           // Required
+	Thumbnail             *InputFile `json:"thumbnail,omitempty"` // Optional: upload only
+	Duration              int64      `json:"duration,omitempty"`  // Optional: duration in seconds
+	Width                 int64      `json:"width,omitempty"`     // Optional
+	Height                int64      `json:"height,omitempty"`    // Optional
+	ShowCaptionAboveMedia bool       `json:"show_caption_above_media,omitempty"`
+	HasSpoiler            bool       `json:"has_spoiler,omitempty"`
 }
 
 type SendVoiceRequest struct {
 	BaseMediaRequest
-	Voice    string `json:"voice"`              // Required: file_path or file_id
-	Duration int64  `json:"duration,omitempty"` // Optional: duration in seconds
+	Voice    *InputFile `json:"voice"`              // Required
+	Duration int64      `json:"duration,omitempty"` // Optional: duration in seconds
 }
 
 type SendVideoNoteRequest struct {
 	BaseMediaRequest
-	VideoNote string `json:"video_note"`         // Required: file_path or file_id
-	Duration  int64  `json:"duration,omitempty"` // Optional: duration in seconds
-	Length    int64  `json:"length,omitempty"`   // Optional
+	VideoNote *InputFile `json:"video_note"`          // Required
+	Thumbnail *InputFile `json:"thumbnail,omitempty"` // Optional: upload only
+	Duration  int64      `json:"duration,omitempty"`  // Optional: duration in seconds
+	Length    int64      `json:"length,omitempty"`    // Optional
 }
 
 type SendStickerRequest struct {
 	BaseMediaRequest
-	Sticker string `json:"sticker"`
-	Emoji   string `json:"emoji,omitempty"` // only for uploaded stickers
-}
-
-// MediaFile represents a file to be sent in a media group
-type MediaFile struct {
-	ParamName string // e.g., "photo", "video"
-	FilePath  string
-	FileType  string // mime type
-	Media     string // this will be used in the media array json
+	Sticker *InputFile `json:"sticker"`         // Required
+	Emoji   string     `json:"emoji,omitempty"` // only for uploaded stickers
 }
 
 // SendMediaGroupRequest represents the structure for sending multiple media files
@@ -155,15 +156,16 @@ type SendMediaGroupRequest struct {
 
 // InputMedia represents a single media in the group
 type InputMedia struct {
-	Type              string    `json:"type"`  // "photo", "video", etc.
-	Media             string    `json:"media"` // file_id or "attach://<file_name>"
-	Caption           string    `json:"caption,omitempty"`
-	ParseMode         ParseMode `json:"parse_mode,omitempty"`
-	HasSpoiler        bool      `json:"has_spoiler,omitempty"`
-	Duration          int       `json:"duration,omitempty"`           // For videos
-	Width             int       `json:"width,omitempty"`              // For videos
-	Height            int       `json:"height,omitempty"`             // For videos
-	SupportsStreaming bool      `json:"supports_streaming,omitempty"` // For videos
+	Type              string     `json:"type"` // "photo", "video", etc.
+	Media             *InputFile `json:"media"`
+	Thumbnail         *InputFile `json:"thumbnail,omitempty"` // upload only, not for photos
+	Caption           string     `json:"caption,omitempty"`
+	ParseMode         ParseMode  `json:"parse_mode,omitempty"`
+	HasSpoiler        bool       `json:"has_spoiler,omitempty"`
+	Duration          int        `json:"duration,omitempty"`           // For videos
+	Width             int        `json:"width,omitempty"`              // For videos
+	Height            int        `json:"height,omitempty"`             // For videos
+	SupportsStreaming bool       `json:"supports_streaming,omitempty"` // For videos
 }
 
 type SendChatActionRequest struct {
@@ -250,6 +252,21 @@ type CallbackAnswerOptions struct {
 
 type EditMessageOptions struct {
 	ParseMode             ParseMode                    `json:"parse_mode,omitempty"`
+	Entities              []models.MessageEntity       `json:"entities,omitempty"` // instead of ParseMode
 	DisableWebPagePreview bool                         `json:"disable_web_page_preview,omitempty"`
 	ReplyMarkup           *models.InlineKeyboardMarkup `json:"reply_markup,omitempty"`
 }
+
+type SetWebhookRequest struct {
+	URL                string     `json:"url"`                            // Required, defaults to the bot's webhook url
+	Certificate        *InputFile `json:"certificate,omitempty"`          // Optional: public key of a self-signed certificate, upload only
+	IPAddress          string     `json:"ip_address,omitempty"`           // Optional: fixed IP instead of resolving the url
+	MaxConnections     int        `json:"max_connections,omitempty"`      // Optional: 1-100, defaults to 40
+	AllowedUpdates     []string   `json:"allowed_updates,omitempty"`      // Optional: empty list for all but chat_member, nil keeps the previous setting
+	DropPendingUpdates bool       `json:"drop_pending_updates,omitempty"` // Optional
+	SecretToken        string     `json:"secret_token,omitempty"`         // Optional: 1-256 of A-Z, a-z, 0-9, _ and -
+}
+
+type DeleteWebhookRequest struct {
+	DropPendingUpdates bool `json:"drop_pending_updates,omitempty"`
+}
@@ -0,0 +1,291 @@
+package tgx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Job is a scheduled API call or handler invocation. Jobs stay in the store
+// until they ran successfully, so they are delivered at least once even when
+// the process restarts in between.
+type Job struct {
+	// ID is generated when empty, scheduling a job with the ID of an existing
+	// one replaces it
+	ID string `json:"id"`
+	// At is the time of the next run, defaults to now for one-off jobs and
+	// to the next match of Cron for repeating ones
+	At time.Time `json:"at"`
+	// Cron repeats the job, e.g. "0 9 * * *" for every day at 9:00 or
+	// "@every 2h". One-off jobs are removed once they ran.
+	Cron string `json:"cron,omitempty"`
+
+	// either Call or Handler is set, calls can't upload files as they are
+	// stored as JSON
+	Call    *APICall        `json:"call,omitempty"`
+	Handler string          `json:"handler,omitempty"` // name given to Scheduler.Handle
+	Payload json.RawMessage `json:"payload,omitempty"` // passed to the handler
+
+	Attempts int `json:"attempts,omitempty"` // failed runs since the last success
+}
+
+// JobHandler runs a job scheduled with its name. Returning an error runs it
+// again later.
+type JobHandler func(ctx context.Context, job Job) error
+
+type SchedulerOptions struct {
+	Store JobStore // defaults to a MemoryJobStore
+	// MaxAttempts is how many times a failing job runs before it is given
+	// up, defaults to 5. Retries back off exponentially from a minute.
+	MaxAttempts int
+	// PollInterval bounds how long jobs added to the store by others wait,
+	// defaults to a minute
+	PollInterval time.Duration
+	// Location of cron schedules, defaults to the local time zone
+	Location *time.Location
+}
+
+// Scheduler runs jobs at given times or on cron schedules. A store must be
+// used by one running scheduler at a time.
+type Scheduler struct {
+	bot      *Bot
+	opts     SchedulerOptions
+	handlers map[string]JobHandler
+	onFailed func(job Job, err error)
+	wake     chan struct{}
+}
+
+func NewScheduler(bot *Bot, opts SchedulerOptions) *Scheduler {
+	if opts.Store == nil {
+		opts.Store = NewMemoryJobStore()
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Minute
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	return &Scheduler{
+		bot:      bot,
+		opts:     opts,
+		handlers: make(map[string]JobHandler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler run by jobs with the given name. Handlers must
+// be registered before Run.
+func (s *Scheduler) Handle(name string, handler JobHandler) {
+	s.handlers[name] = handler
+}
+
+// OnFailed sets the hook called when a job is given up.
+func (s *Scheduler) OnFailed(hook func(job Job, err error)) {
+	s.onFailed = hook
+}
+
+// Schedule validates and stores a job.
+func (s *Scheduler) Schedule(ctx context.Context, job Job) (Job, error) {
+	if (job.Call == nil) == (job.Handler == "") {
+		return Job{}, &BotError{Code: http.StatusBadRequest, Message: "job needs either a call or a handler"}
+	}
+	if job.Call != nil && job.Call.Method == "" {
+		return Job{}, &BotError{Code: http.StatusBadRequest, Message: "job call has no method"}
+	}
+	if job.Cron != "" {
+		schedule, err := parseCron(job.Cron)
+		if err != nil {
+			return Job{}, &BotError{Code: http.StatusBadRequest, Message: "invalid job schedule", Err: err}
+		}
+		if job.At.IsZero() {
+			job.At = schedule.next(time.Now().In(s.opts.Location))
+		}
+	}
+	if job.At.IsZero() {
+		job.At = time.Now()
+	}
+	if job.ID == "" {
+		id, err := newJobID()
+		if err != nil {
+			return Job{}, &BotError{Code: http.StatusInternalServerError, Message: "failed to generate job id", Err: err}
+		}
+		job.ID = id
+	}
+
+	if err := s.opts.Store.SaveJob(ctx, job); err != nil {
+		return Job{}, &BotError{Code: http.StatusInternalServerError, Message: "failed to save job", Err: err}
+	}
+	s.notify()
+	return job, nil
+}
+
+// SendAt makes the API call at the given time.
+func (s *Scheduler) SendAt(ctx context.Context, at time.Time, call *APICall) (Job, error) {
+	return s.Schedule(ctx, Job{At: at, Call: call})
+}
+
+// SendAfter makes the API call once d has passed, e.g. for reminders.
+func (s *Scheduler) SendAfter(ctx context.Context, d time.Duration, call *APICall) (Job, error) {
+	return s.SendAt(ctx, time.Now().Add(d), call)
+}
+
+// Cancel removes a job.
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	if err := s.opts.Store.DeleteJob(ctx, id); err != nil {
+		return &BotError{Code: http.StatusInternalServerError, Message: "failed to delete job", Err: err}
+	}
+	return nil
+}
+
+// Run runs due jobs until ctx is done. Jobs run one at a time in the order
+// they are due; handlers sending many messages should use Broadcast.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		jobs, err := s.opts.Store.Jobs(ctx)
+		if err != nil {
+			s.bot.logger.Error("Failed to load jobs", "error", err)
+		}
+		sort.Slice(jobs, func(i, j int) bool { return jobs[i].At.Before(jobs[j].At) })
+
+		wait := s.opts.PollInterval
+		ran := false
+		for _, job := range jobs {
+			if until := time.Until(job.At); until > 0 {
+				wait = min(wait, until)
+				break
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := s.run(ctx, job); err != nil {
+				// the job is still due, wait for the store to recover
+				s.bot.logger.Error("Failed to store job outcome", "job", job.ID, "error", err)
+				ran = false
+				break
+			}
+			ran = true
+		}
+		if ran {
+			// jobs that ran may be due again soon
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// notify wakes Run so it sees a newly scheduled job.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run runs a job and then removes, reschedules or retries it. It returns
+// the store's error when that fails.
+func (s *Scheduler) run(ctx context.Context, job Job) error {
+	start := time.Now()
+	err := s.execute(ctx, job)
+	if err != nil && ctx.Err() != nil {
+		// interrupted, the job runs again after a restart
+		return nil
+	}
+	s.bot.logger.Debug("Ran job", "job", job.ID, "duration", time.Since(start), "error", err)
+
+	// the outcome is stored even when ctx is done
+	ctx = context.WithoutCancel(ctx)
+	switch {
+	case err == nil:
+		job.Attempts = 0
+		return s.reschedule(ctx, job)
+	case job.Attempts+1 >= s.opts.MaxAttempts || !retryable(err):
+		s.bot.logger.Error("Job failed, giving up", "job", job.ID, "attempts", job.Attempts+1, "error", err)
+		if s.onFailed != nil {
+			s.onFailed(job, err)
+		}
+		job.Attempts = 0
+		return s.reschedule(ctx, job)
+	default:
+		job.Attempts++
+		wait := time.Minute << (job.Attempts - 1)
+		if retryAfter, ok := RetryAfter(err); ok {
+			wait = retryAfter
+		}
+		s.bot.logger.Warn("Job failed, retrying", "job", job.ID, "attempts", job.Attempts, "retry_in", wait, "error", err)
+		job.At = time.Now().Add(wait)
+		return s.opts.Store.SaveJob(ctx, job)
+	}
+}
+
+// reschedule moves a repeating job to its next run and removes a one-off
+// job.
+func (s *Scheduler) reschedule(ctx context.Context, job Job) error {
+	if job.Cron != "" {
+		schedule, err := parseCron(job.Cron)
+		if err == nil {
+			job.At = schedule.next(time.Now().In(s.opts.Location))
+		}
+		if err == nil && !job.At.IsZero() {
+			return s.opts.Store.SaveJob(ctx, job)
+		}
+	}
+	return s.opts.Store.DeleteJob(ctx, job.ID)
+}
+
+func (s *Scheduler) execute(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = s.bot.recovered(nil, r)
+		}
+	}()
+
+	if job.Call != nil {
+		_, err = s.bot.callAPI(ctx, job.Call.Method, job.Call.Params)
+		return err
+	}
+	handler, ok := s.handlers[job.Handler]
+	if !ok {
+		return fmt.Errorf("no handler registered for job %q", job.Handler)
+	}
+	return handler(ctx, job)
+}
+
+// retryable reports whether a failed job may succeed when run again. API
+// calls rejected by Telegram, e.g. to users who blocked the bot, are not
+// retried.
+func retryable(err error) bool {
+	if _, ok := RetryAfter(err); ok {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= 500
+	}
+	return true
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,257 @@
+package tgx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+// runScheduler runs s until the test ends.
+func runScheduler(t *testing.T, s *tgx.Scheduler) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func sendCall(chatID int64, text string) *tgx.APICall {
+	return &tgx.APICall{Method: "sendMessage", Params: map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}}
+}
+
+// waitForJobs waits until the store holds n jobs.
+func waitForJobs(t *testing.T, store tgx.JobStore, n int) []tgx.Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		jobs, err := store.Jobs(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(jobs) == n {
+			return jobs
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("store holds %d jobs, want %d", len(jobs), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSchedulerSendAfter(t *testing.T) {
+	bot, srv := newTestBot(t)
+	store := tgx.NewMemoryJobStore()
+	s := tgx.NewScheduler(bot, tgx.SchedulerOptions{Store: store})
+	runScheduler(t, s)
+
+	job, err := s.SendAfter(context.Background(), 20*time.Millisecond, sendCall(42, "reminder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(job.ID) != 16 {
+		t.Errorf("generated id = %q", job.ID)
+	}
+	if !srv.WaitForCalls(1, 2*time.Second) {
+		t.Fatal("the job didn't run")
+	}
+	call, _ := srv.LastCall("sendMessage")
+	if call.Int("chat_id") != 42 || call.String("text") != "reminder" {
+		t.Errorf("call = %+v", call)
+	}
+	// one-off jobs are removed once they ran
+	waitForJobs(t, store, 0)
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	bot, srv := newTestBot(t)
+	s := tgx.NewScheduler(bot, tgx.SchedulerOptions{})
+	runScheduler(t, s)
+
+	job, err := s.SendAfter(context.Background(), 50*time.Millisecond, sendCall(42, "reminder"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cancel(context.Background(), job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if srv.WaitForCalls(1, 150*time.Millisecond) {
+		t.Error("a cancelled job ran")
+	}
+}
+
+func TestSchedulerHandlerJob(t *testing.T) {
+	bot, _ := newTestBot(t)
+	s := tgx.NewScheduler(bot, tgx.SchedulerOptions{})
+	got := make(chan string, 1)
+	s.Handle("digest", func(ctx context.Context, job tgx.Job) error {
+		var payload struct{ Topic string }
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return err
+		}
+		got <- payload.Topic
+		return nil
+	})
+	runScheduler(t, s)
+
+	if _, err := s.Schedule(context.Background(), tgx.Job{Handler: "digest", Payload: json.RawMessage(`{"Topic":"go"}`)}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case topic := <-got:
+		if topic != "go" {
+			t.Errorf("topic = %q", topic)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the handler didn't run")
+	}
+}
+
+func TestSchedulerCronJob(t *testing.T) {
+	bot, srv := newTestBot(t)
+	store := tgx.NewMemoryJobStore()
+	s := tgx.NewScheduler(bot, tgx.SchedulerOptions{Store: store})
+	runScheduler(t, s)
+
+	_, err := s.Schedule(context.Background(), tgx.Job{ID: "daily", At: time.Now(), Cron: "@every 1h", Call: sendCall(42, "digest")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !srv.WaitForCalls(1, 2*time.Second) {
+		t.Fatal("the job didn't run")
+	}
+	// repeating jobs move to their next run
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		jobs := waitForJobs(t, store, 1)
+		if time.Until(jobs[0].At) > 59*time.Minute {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job wasn't rescheduled: %+v", jobs[0])
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSchedulerInvalidJobs(t *testing.T) {
+	bot, _ := newTestBot(t)
+	s := tgx.NewScheduler(bot, tgx.SchedulerOptions{})
+	for name, job := range map[string]tgx.Job{
+		"nothing to run":   {},
+		"call and handler": {Call: sendCall(1, "hi"), Handler: "digest"},
+		"no method":        {Call: &tgx.APICall{}},
+		"bad cron":         {Cron: "every day", Call: sendCall(1, "hi")},
+	} {
+		if _, err := s.Schedule(context.Background(), job); !isBadRequest(err) {
+			t.Errorf("%s: err = %v, want a bad request", name, err)
+		}
+	}
+}
+
+// Calls Telegram rejects are given up right away, other failures are
+// retried later.
+func TestSchedulerFailures(t *testing.T) {
+	bot, srv := newTestBot(t)
+	store := tgx.NewMemoryJobStore()
+	s := tgx.NewScheduler(bot, tgx.SchedulerOptions{Store: store})
+	failed := make(chan error, 1)
+	s.OnFailed(func(job tgx.Job, err error) { failed <- err })
+	s.Handle("flaky", func(ctx context.Context, job tgx.Job) error {
+		return errors.New("backend down")
+	})
+	srv.Queue("sendMessage", tgxtest.Forbidden())
+	runScheduler(t, s)
+
+	if _, err := s.SendAfter(context.Background(), 0, sendCall(42, "hi")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-failed:
+		if !errors.Is(err, tgx.ErrBotBlocked) {
+			t.Errorf("failed with %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the job wasn't given up")
+	}
+	waitForJobs(t, store, 0)
+
+	if _, err := s.Schedule(context.Background(), tgx.Job{ID: "flaky", Handler: "flaky"}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		jobs := waitForJobs(t, store, 1)
+		if jobs[0].Attempts == 1 {
+			if wait := time.Until(jobs[0].At); wait < 50*time.Second || wait > time.Minute {
+				t.Errorf("retry in %v, want a minute", wait)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job wasn't retried: %+v", jobs[0])
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSchedulerRecoversPanics(t *testing.T) {
+	bot, _ := newTestBot(t)
+	panics := make(chan *models.Update, 1)
+	bot.OnPanic(func(update *models.Update, err *tgx.PanicError) { panics <- update })
+	s := tgx.NewScheduler(bot, tgx.SchedulerOptions{})
+	s.Handle("broken", func(ctx context.Context, job tgx.Job) error {
+		panic("broken job")
+	})
+	runScheduler(t, s)
+
+	if _, err := s.Schedule(context.Background(), tgx.Job{Handler: "broken"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case update := <-panics:
+		if update != nil {
+			t.Errorf("update = %v, want nil for jobs", update)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the panic wasn't reported")
+	}
+}
+
+// Jobs due while the bot was down run once it's back.
+func TestSchedulerRunsStoredJobs(t *testing.T) {
+	bot, srv := newTestBot(t)
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	s := tgx.NewScheduler(bot, tgx.SchedulerOptions{Store: tgx.NewFileJobStore(path)})
+	if _, err := s.SendAt(context.Background(), time.Now().Add(-time.Minute), sendCall(-1001234567890123, "missed")); err != nil {
+		t.Fatal(err)
+	}
+
+	// after a restart
+	store := tgx.NewFileJobStore(path)
+	runScheduler(t, tgx.NewScheduler(bot, tgx.SchedulerOptions{Store: store}))
+
+	if !srv.WaitForCalls(1, 2*time.Second) {
+		t.Fatal("the stored job didn't run")
+	}
+	call, _ := srv.LastCall("sendMessage")
+	if call.Int("chat_id") != -1001234567890123 || call.String("text") != "missed" {
+		t.Errorf("call = %+v", call)
+	}
+	waitForJobs(t, store, 0)
+}
@@ -0,0 +1,164 @@
+package tgx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+type ServerOptions struct {
+	// WebhookPath defaults to the path of the webhook url given to NewBot
+	WebhookPath string
+	HealthPath  string // defaults to /healthz
+	ReadyPath   string // defaults to /readyz
+
+	// serve TLS directly when both are set, otherwise a proxy is expected
+	// to terminate it
+	CertFile string
+	KeyFile  string
+
+	// Webhook holds the options given to SetWebhookWithOpts on startup. Its
+	// SecretToken is checked from the first request on, also with
+	// SkipSetWebhook.
+	Webhook *SetWebhookRequest
+	// SkipSetWebhook leaves registering the webhook to someone else, e.g.
+	// when several replicas run behind one url
+	SkipSetWebhook          bool
+	DeleteWebhookOnShutdown bool
+
+	// ShutdownTimeout bounds how long in-flight updates are drained for,
+	// defaults to 10 seconds
+	ShutdownTimeout time.Duration
+}
+
+// ListenAndServe serves the webhook on addr until ctx is done or the process
+// gets SIGINT or SIGTERM. It registers the webhook once listening, and on
+// shutdown stops accepting updates and waits for in-flight handlers and the
+// worker pool to finish. /readyz reports 503 until the webhook is set and
+// again while shutting down, /healthz always reports 200 while serving.
+func (b *Bot) ListenAndServe(ctx context.Context, addr string, opts *ServerOptions) error {
+	if opts == nil {
+		opts = &ServerOptions{}
+	}
+	o := *opts
+	if o.WebhookPath == "" {
+		o.WebhookPath = "/"
+		if u, err := url.Parse(b.webhookURL); err == nil && u.Path != "" {
+			o.WebhookPath = u.Path
+		}
+	}
+	if o.HealthPath == "" {
+		o.HealthPath = "/healthz"
+	}
+	if o.ReadyPath == "" {
+		o.ReadyPath = "/readyz"
+	}
+	if o.ShutdownTimeout <= 0 {
+		o.ShutdownTimeout = 10 * time.Second
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var ready atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc(o.WebhookPath, b.HandleWebhook)
+	mux.HandleFunc(o.HealthPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc(o.ReadyPath, func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	req := o.Webhook
+	if req == nil {
+		req = &SetWebhookRequest{}
+	}
+	// updates arriving before the webhook is registered are checked too,
+	// e.g. when it was registered with the same secret by a previous run
+	if req.SecretToken != "" {
+		b.UseSecretToken(req.SecretToken)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return &BotError{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to listen",
+			Err:     err,
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if o.CertFile != "" && o.KeyFile != "" {
+			serveErr <- srv.ServeTLS(ln, o.CertFile, o.KeyFile)
+		} else {
+			serveErr <- srv.Serve(ln)
+		}
+	}()
+	b.logger.Info("Serving webhook", "addr", ln.Addr().String(), "path", o.WebhookPath)
+
+	if !o.SkipSetWebhook {
+		if err := b.SetWebhookWithOpts(req); err != nil {
+			b.shutdown(srv, &o, false)
+			return fmt.Errorf("failed to set webhook: %w", err)
+		}
+	}
+	ready.Store(true)
+
+	select {
+	case <-ctx.Done():
+		b.logger.Info("Shutting down webhook server")
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			b.shutdown(srv, &o, false)
+			return &BotError{
+				Code:    http.StatusInternalServerError,
+				Message: "Webhook server failed",
+				Err:     err,
+			}
+		}
+	}
+
+	ready.Store(false)
+	return b.shutdown(srv, &o, o.DeleteWebhookOnShutdown)
+}
+
+// shutdown stops the server and drains in-flight updates within the timeout.
+func (b *Bot) shutdown(srv *http.Server, opts *ServerOptions, deleteWebhook bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+	defer cancel()
+
+	if deleteWebhook {
+		if err := b.DeleteWebhook(); err != nil {
+			b.logger.Error("Failed to delete webhook", "error", err)
+		}
+	}
+
+	var errs []error
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain webhook server: %w", err))
+	}
+	if err := b.StopWorkerPool(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain worker pool: %w", err))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,164 @@
+package tgx_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// serverClient doesn't keep connections open, so they don't hold up the
+// server's shutdown.
+var serverClient = &http.Client{
+	Transport: &http.Transport{DisableKeepAlives: true},
+	Timeout:   5 * time.Second,
+}
+
+// getStatus returns the status of a GET request, 0 when it failed.
+func getStatus(url string) int {
+	resp, err := serverClient.Get(url)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func waitReady(t *testing.T, url string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if getStatus(url) == http.StatusOK {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s not ready", url)
+}
+
+// shutdownTimeout is well above the 5s net/http gives connections that
+// never sent a request before dropping them on shutdown.
+const shutdownTimeout = 15 * time.Second
+
+func TestListenAndServe(t *testing.T) {
+	bot, srv := newTestBot(t)
+	srv.SetDirect(false)
+	handled := make(chan string, 1)
+	bot.OnMessage("Text", func(ctx *tgx.Context) error {
+		handled <- ctx.Text
+		return nil
+	})
+
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.ListenAndServe(ctx, addr, &tgx.ServerOptions{
+			WebhookPath:             "/hook",
+			Webhook:                 &tgx.SetWebhookRequest{URL: "https://example.com/hook", SecretToken: "s3cret"},
+			DeleteWebhookOnShutdown: true,
+		})
+	}()
+	base := fmt.Sprintf("http://%s", addr)
+	waitReady(t, base+"/readyz")
+
+	if got := srv.AssertCalled(t, "setWebhook").String("secret_token"); got != "s3cret" {
+		t.Errorf("secret_token = %q", got)
+	}
+	if code := getStatus(base + "/healthz"); code != http.StatusOK {
+		t.Errorf("healthz = %d", code)
+	}
+
+	post := func(secret string) int {
+		req, _ := http.NewRequest(http.MethodPost, base+"/hook", strings.NewReader(testUpdate))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+		resp, err := serverClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := post("wrong"); code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: status = %d, want 401", code)
+	}
+	if code := post("s3cret"); code != http.StatusOK {
+		t.Errorf("status = %d, want 200", code)
+	}
+	if text := <-handled; text != "hi" {
+		t.Errorf("handled %q", text)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ListenAndServe = %v", err)
+		}
+	case <-time.After(shutdownTimeout):
+		t.Fatal("ListenAndServe didn't return after cancel")
+	}
+	srv.AssertCalled(t, "deleteWebhook")
+}
+
+// The secret is checked from the first request on, before the webhook is
+// registered.
+func TestListenAndServeSecretBeforeRegistered(t *testing.T) {
+	bot, srv := newTestBot(t)
+	registered := make(chan struct{})
+	srv.Handle("setWebhook", func(c tgxtest.Call) tgxtest.Response {
+		<-registered
+		return tgxtest.OK(true)
+	})
+
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- bot.ListenAndServe(ctx, addr, &tgx.ServerOptions{
+			Webhook: &tgx.SetWebhookRequest{URL: "https://example.com/", SecretToken: "s3cret"},
+		})
+	}()
+	waitReady(t, fmt.Sprintf("http://%s/healthz", addr))
+
+	resp, err := serverClient.Post(fmt.Sprintf("http://%s/", addr), "application/json", strings.NewReader(testUpdate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", resp.StatusCode)
+	}
+	if code := getStatus(fmt.Sprintf("http://%s/readyz", addr)); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz = %d before the webhook was set", code)
+	}
+
+	close(registered)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ListenAndServe = %v", err)
+		}
+	case <-time.After(shutdownTimeout):
+		t.Fatal("ListenAndServe didn't return after cancel")
+	}
+}
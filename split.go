@@ -0,0 +1,121 @@
+package tgx
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/format"
+)
+
+// SendMessageSplit sends req, splitting the text into several messages when
+// it is longer than format.MaxMessageLength. Chunks are cut at paragraph,
+// line or word boundaries and keep their formatting. Reply parameters apply
+// to the first message and the reply markup to the last one. On error the
+// messages sent so far are returned along with it.
+func (b *Bot) SendMessageSplit(req *SendMessageRequest) ([]*models.Message, error) {
+	// validates the request before anything is sent
+	payload, err := sendMessagePayload(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if format.UTF16Len(req.Text) <= format.MaxMessageLength {
+		msg, err := b.sendMessageWithResult(payload)
+		if err != nil {
+			return nil, err
+		}
+		return []*models.Message{msg}, nil
+	}
+
+	chunks, err := splitMessage(req.Text, req.ParseMode, req.Entities, format.MaxMessageLength)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*models.Message, 0, len(chunks))
+	for i, chunk := range chunks {
+		part := &SendMessageRequest{
+			ChatId:   req.ChatId,
+			Text:     chunk.Text,
+			Entities: chunk.Entities,
+		}
+		if i == 0 {
+			part.ReplyParams = req.ReplyParams
+		}
+		if i == len(chunks)-1 {
+			part.ReplyMarkup = req.ReplyMarkup
+		}
+
+		payload, err := sendMessagePayload(part)
+		if err != nil {
+			return messages, err
+		}
+		msg, err := b.sendMessageWithResult(payload)
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// ReplySplit is SendMessageSplit for the current chat.
+func (ctx *Context) ReplySplit(req *SendMessageRequest) ([]*models.Message, error) {
+	reply := *req
+	reply.ChatId = ctx.ChatID
+	return ctx.bot.WithContext(ctx.Context()).SendMessageSplit(&reply)
+}
+
+// splitMessage parses formatted text into entities and splits it into chunks
+// of at most limit characters.
+func splitMessage(text string, mode ParseMode, entities []models.MessageEntity, limit int) ([]format.Chunk, error) {
+	text, entities, err := parseText(text, mode, entities)
+	if err != nil {
+		return nil, err
+	}
+	return format.Split(text, entities, limit), nil
+}
+
+// splitCaption parses a formatted caption and splits off the overflow to
+// send as messages.
+func splitCaption(text string, mode ParseMode) (format.Chunk, []format.Chunk, error) {
+	text, entities, err := parseText(text, mode, nil)
+	if err != nil {
+		return format.Chunk{}, nil, err
+	}
+	caption, overflow := format.SplitCaption(text, entities)
+	return caption, overflow, nil
+}
+
+func parseText(text string, mode ParseMode, entities []models.MessageEntity) (string, []models.MessageEntity, error) {
+	if mode == "" {
+		return text, entities, nil
+	}
+	plain, parsed, err := format.Parse(string(mode), text)
+	if err != nil {
+		return "", nil, &BotError{
+			Code:    http.StatusBadRequest,
+			Message: "Failed to parse formatted text",
+			Err:     err,
+		}
+	}
+	return plain, parsed, nil
+}
+
+func (b *Bot) sendMessageWithResult(payload map[string]interface{}) (*models.Message, error) {
+	result, err := b.makeAPIRequestWithResult("sendMessage", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg models.Message
+	if err := json.Unmarshal(result, &msg); err != nil {
+		return nil, &BotError{
+			Code:    http.StatusInternalServerError,
+			Message: "failed to decode message",
+			Err:     err,
+		}
+	}
+	return &msg, nil
+}
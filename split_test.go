@@ -0,0 +1,75 @@
+package tgx_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/models"
+	"github.com/harshyadavone/tgx/pkg/format"
+)
+
+func TestSendMessageSplit(t *testing.T) {
+	bot, srv := newTestBot(t)
+	text := "<b>" + strings.Repeat("word ", 1000) + "</b>" + strings.Repeat("tail ", 200)
+
+	messages, err := bot.SendMessageSplit(&tgx.SendMessageRequest{ChatId: 1, Text: text, ParseMode: tgx.HTML})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := srv.CallsTo("sendMessage")
+	if len(messages) != 2 || len(calls) != 2 {
+		t.Fatalf("sent %d messages in %d calls, want 2", len(messages), len(calls))
+	}
+	for _, call := range calls {
+		if n := format.UTF16Len(call.String("text")); n > format.MaxMessageLength {
+			t.Errorf("message is %d units long", n)
+		}
+		if call.String("parse_mode") != "" {
+			t.Errorf("chunk sent with parse_mode, entities expected")
+		}
+	}
+	var entities []models.MessageEntity
+	if err := calls[0].Decode("entities", &entities); err != nil || len(entities) != 1 || entities[0].Type != models.EntityBold {
+		t.Errorf("first chunk entities = %+v, %v", entities, err)
+	}
+}
+
+func TestSendPhotoSplitCaption(t *testing.T) {
+	bot, srv := newTestBot(t)
+	caption := strings.Repeat("caption ", 300) // 2400 units
+
+	req := &tgx.SendPhotoRequest{Photo: tgx.FileFromID("photo")}
+	req.ChatId = 1
+	req.Caption = caption
+	req.SplitCaption = true
+	if err := bot.SendPhoto(req); err != nil {
+		t.Fatal(err)
+	}
+
+	photo := srv.AssertCalled(t, "sendPhoto")
+	sent := photo.String("caption")
+	if n := format.UTF16Len(sent); n > format.MaxCaptionLength {
+		t.Errorf("caption is %d units long", n)
+	}
+	follow := srv.AssertCalled(t, "sendMessage")
+	if got := sent + " " + follow.String("text"); got != strings.TrimSpace(caption) {
+		t.Errorf("caption and follow-up don't add up to the caption")
+	}
+}
+
+func TestSendPhotoCaption(t *testing.T) {
+	bot, srv := newTestBot(t)
+	req := &tgx.SendPhotoRequest{Photo: tgx.FileFromID("photo")}
+	req.ChatId = 1
+	req.Caption = "<b>bold</b>"
+	req.ParseMode = tgx.HTML
+	if err := bot.SendPhoto(req); err != nil {
+		t.Fatal(err)
+	}
+	call := srv.AssertCalled(t, "sendPhoto")
+	if call.String("caption") != "<b>bold</b>" || call.String("parse_mode") != "HTML" {
+		t.Errorf("caption %q parse_mode %q", call.String("caption"), call.String("parse_mode"))
+	}
+	srv.AssertNotCalled(t, "sendMessage")
+}
@@ -0,0 +1,86 @@
+package tgx
+
+import (
+	"context"
+)
+
+// Tracer opens spans around update handling, handler execution and Bot API
+// calls. pkg/otel adapts an OpenTelemetry TracerProvider.
+type Tracer interface {
+	// Start opens a span as a child of the one in ctx and returns a context
+	// carrying the new span.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// RecordError records err and marks the span as failed.
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key/value pair describing a span. Values are strings,
+// bools, ints, int64s or float64s.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func Attr(key string, value interface{}) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Span names and attribute keys used by the bot
+const (
+	SpanUpdate  = "tgx.update"
+	SpanHandler = "tgx.handler"
+	SpanAPICall = "tgx.api_call"
+
+	AttrUpdateID   = "tgx.update_id"
+	AttrUpdateType = "tgx.update_type"
+	AttrChatID     = "tgx.chat_id"
+	AttrUserID     = "tgx.user_id"
+	AttrHandler    = "tgx.handler"
+	AttrMethod     = "tgx.method"
+	AttrStatus     = "http.response.status_code"
+	AttrRetryCount = "tgx.retry_count"
+)
+
+// UseTracer traces the bot with t, nil turns tracing off. Calls made on the
+// Bot start a new trace unless it was given a context with WithContext.
+func (b *Bot) UseTracer(t Tracer) {
+	if t == nil {
+		t = nopTracer{}
+	}
+	b.tracer = t
+}
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...Attribute) {}
+func (nopSpan) RecordError(error)          {}
+func (nopSpan) End()                       {}
+
+// Context returns the context of the update being handled, it carries the
+// handler's span so calls to other services can be traced as its children.
+func (ctx *Context) Context() context.Context {
+	if ctx.reqCtx == nil {
+		return context.Background()
+	}
+	return ctx.reqCtx
+}
+
+// Context returns the context of the update being handled, it carries the
+// handler's span so calls to other services can be traced as its children.
+func (ctx *CallbackContext) Context() context.Context {
+	if ctx.reqCtx == nil {
+		return context.Background()
+	}
+	return ctx.reqCtx
+}
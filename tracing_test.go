@@ -0,0 +1,154 @@
+package tgx_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+// fakeTracer records the spans started by the bot.
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+type fakeSpan struct {
+	name   string
+	parent *fakeSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+type spanKey struct{}
+
+func (t *fakeTracer) Start(ctx context.Context, name string, attrs ...tgx.Attribute) (context.Context, tgx.Span) {
+	parent, _ := ctx.Value(spanKey{}).(*fakeSpan)
+	s := &fakeSpan{name: name, parent: parent, attrs: make(map[string]interface{})}
+	s.SetAttributes(attrs...)
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (t *fakeTracer) named(name string) []*fakeSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var spans []*fakeSpan
+	for _, s := range t.spans {
+		if s.name == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func (s *fakeSpan) SetAttributes(attrs ...tgx.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *fakeSpan) RecordError(err error) { s.err = err }
+func (s *fakeSpan) End()                  { s.ended = true }
+
+func TestTracingSpanTree(t *testing.T) {
+	bot, srv := newTestBot(t)
+	tracer := &fakeTracer{}
+	bot.UseTracer(tracer)
+	bot.OnUpdateError(nil)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		if err := ctx.Reply("one"); err != nil {
+			return err
+		}
+		return ctx.Reply("two")
+	})
+	srv.Queue("sendMessage", tgxtest.OK(nil), tgxtest.BadRequest("message is too long"))
+
+	bot.ProcessUpdate(commandUpdate(5, "/start"))
+
+	updates := tracer.named(tgx.SpanUpdate)
+	handlers := tracer.named(tgx.SpanHandler)
+	calls := tracer.named(tgx.SpanAPICall)
+	if len(updates) != 1 || len(handlers) != 1 || len(calls) != 2 {
+		t.Fatalf("got %d update, %d handler and %d call spans", len(updates), len(handlers), len(calls))
+	}
+
+	update, handler := updates[0], handlers[0]
+	if update.parent != nil || update.attrs[tgx.AttrUpdateID] != 5 || update.attrs[tgx.AttrChatID] != int64(42) {
+		t.Errorf("update span = %+v", update)
+	}
+	if handler.parent != update || handler.attrs[tgx.AttrHandler] != "command:start" || handler.err == nil {
+		t.Errorf("handler span = %+v", handler)
+	}
+	for i, status := range []int{200, 400} {
+		call := calls[i]
+		if call.parent != handler || call.attrs[tgx.AttrMethod] != "sendMessage" || call.attrs[tgx.AttrStatus] != status {
+			t.Errorf("call span %d = %+v", i, call)
+		}
+		if (call.err != nil) != (status != 200) {
+			t.Errorf("call span %d error = %v", i, call.err)
+		}
+	}
+	for _, s := range tracer.named(tgx.SpanAPICall) {
+		if !s.ended {
+			t.Errorf("span %s not ended", s.name)
+		}
+	}
+	if !update.ended || !handler.ended {
+		t.Error("spans not ended")
+	}
+}
+
+func TestTracingDownloadKeepsParent(t *testing.T) {
+	bot, srv := newTestBot(t)
+	tracer := &fakeTracer{}
+	bot.UseTracer(tracer)
+	srv.Queue("getFile", tgxtest.Response{ErrorCode: 400, Description: "Bad Request: invalid file_id"})
+
+	ctx, parent := tracer.Start(context.Background(), "request")
+	if err := bot.DownloadFile(ctx, "missing", io.Discard); err == nil {
+		t.Fatal("download of a missing file succeeded")
+	}
+	calls := tracer.named(tgx.SpanAPICall)
+	if len(calls) != 1 || calls[0].parent != parent || calls[0].attrs[tgx.AttrMethod] != "getFile" {
+		t.Errorf("getFile span = %+v", calls)
+	}
+}
+
+// Calls made on the bot join the trace of the context it was given.
+func TestTracingBotWithContext(t *testing.T) {
+	bot, _ := newTestBot(t)
+	tracer := &fakeTracer{}
+	bot.UseTracer(tracer)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		if err := bot.WithContext(ctx.Context()).SendMessage(7, "direct"); err != nil {
+			return err
+		}
+		if _, err := ctx.ReplySplit(&tgx.SendMessageRequest{Text: "split"}); err != nil {
+			return err
+		}
+		return bot.SendMessage(7, "detached")
+	})
+
+	bot.ProcessUpdate(commandUpdate(1, "/start"))
+
+	handlers := tracer.named(tgx.SpanHandler)
+	calls := tracer.named(tgx.SpanAPICall)
+	if len(handlers) != 1 || len(calls) != 3 {
+		t.Fatalf("got %d handler and %d call spans", len(handlers), len(calls))
+	}
+	for i, call := range calls[:2] {
+		if call.parent != handlers[0] {
+			t.Errorf("call %d isn't a child of the handler span", i)
+		}
+	}
+	if calls[2].parent != nil {
+		t.Error("a call without a context joined the handler's trace")
+	}
+}
@@ -0,0 +1,67 @@
+package tgx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// APICall is an outgoing Bot API call as seen by API middleware.
+type APICall struct {
+	Method string `json:"method"`
+	// Params are encoded as JSON, or as multipart form data when they
+	// contain uploads
+	Params map[string]interface{} `json:"params,omitempty"`
+	// Header is added to the HTTP request
+	Header http.Header `json:"header,omitempty"`
+}
+
+// APIFunc performs a Bot API call and returns its result.
+type APIFunc func(ctx context.Context, call *APICall) (json.RawMessage, error)
+
+// APIMiddleware wraps the transport every Bot API call goes through, e.g. to
+// add headers, log payloads or inject faults. It may change the call before
+// passing it on, or answer it without calling next.
+type APIMiddleware func(next APIFunc) APIFunc
+
+// UseAPIMiddleware adds middleware to the API transport, the first one added
+// sees calls first. Calls returned in webhook responses (UseWebhookReplies)
+// aren't sent by the bot and don't go through the transport.
+func (b *Bot) UseAPIMiddleware(middleware ...APIMiddleware) {
+	b.apiMiddleware = append(b.apiMiddleware, middleware...)
+}
+
+// BeforeRequest calls hook before every Bot API call. The hook may modify the
+// call; returning an error fails the call with that error without sending it.
+func (b *Bot) BeforeRequest(hook func(ctx context.Context, call *APICall) error) {
+	b.UseAPIMiddleware(func(next APIFunc) APIFunc {
+		return func(ctx context.Context, call *APICall) (json.RawMessage, error) {
+			if err := hook(ctx, call); err != nil {
+				return nil, err
+			}
+			return next(ctx, call)
+		}
+	})
+}
+
+// AfterResponse calls hook after every Bot API call with its outcome.
+func (b *Bot) AfterResponse(hook func(ctx context.Context, call *APICall, result json.RawMessage, err error, duration time.Duration)) {
+	b.UseAPIMiddleware(func(next APIFunc) APIFunc {
+		return func(ctx context.Context, call *APICall) (json.RawMessage, error) {
+			start := time.Now()
+			result, err := next(ctx, call)
+			hook(ctx, call, result, err, time.Since(start))
+			return result, err
+		}
+	})
+}
+
+// roundTrip sends the call through the middleware to the Bot API.
+func (b *Bot) roundTrip(ctx context.Context, call *APICall) (json.RawMessage, error) {
+	send := APIFunc(b.doAPIRequest)
+	for i := len(b.apiMiddleware) - 1; i >= 0; i-- {
+		send = b.apiMiddleware[i](send)
+	}
+	return send(ctx, call)
+}
@@ -0,0 +1,152 @@
+package tgx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func TestAPIMiddlewareOrder(t *testing.T) {
+	bot, _ := newTestBot(t)
+	var order []string
+	for _, name := range []string{"first", "second"} {
+		bot.UseAPIMiddleware(func(next tgx.APIFunc) tgx.APIFunc {
+			return func(ctx context.Context, call *tgx.APICall) (json.RawMessage, error) {
+				order = append(order, name+" before")
+				result, err := next(ctx, call)
+				order = append(order, name+" after")
+				return result, err
+			}
+		})
+	}
+
+	if err := bot.SendMessage(42, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first before", "second before", "second after", "first after"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestBeforeRequestModifiesCall(t *testing.T) {
+	bot, srv := newTestBot(t)
+	bot.BeforeRequest(func(ctx context.Context, call *tgx.APICall) error {
+		call.Params["disable_notification"] = true
+		return nil
+	})
+
+	if err := bot.SendMessage(42, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	call, _ := srv.LastCall("sendMessage")
+	if call.Params["disable_notification"] != true {
+		t.Errorf("params = %v", call.Params)
+	}
+}
+
+func TestBeforeRequestHeader(t *testing.T) {
+	var header http.Header
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	defer api.Close()
+
+	bot := tgx.NewBot(tgxtest.Token, "", nil)
+	bot.UseAPIServer(api.URL, false)
+	bot.BeforeRequest(func(ctx context.Context, call *tgx.APICall) error {
+		call.Header.Set("X-Request-Id", "abc")
+		return nil
+	})
+
+	if err := bot.SendMessage(42, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("X-Request-Id"); got != "abc" {
+		t.Errorf("X-Request-Id = %q", got)
+	}
+}
+
+func TestBeforeRequestFailsCall(t *testing.T) {
+	bot, srv := newTestBot(t)
+	injected := errors.New("injected fault")
+	bot.BeforeRequest(func(ctx context.Context, call *tgx.APICall) error {
+		return injected
+	})
+
+	if err := bot.SendMessage(42, "hi"); !errors.Is(err, injected) {
+		t.Errorf("err = %v, want the injected fault", err)
+	}
+	if calls := srv.Calls(); len(calls) != 0 {
+		t.Errorf("%d calls reached the server", len(calls))
+	}
+}
+
+func TestAfterResponse(t *testing.T) {
+	bot, srv := newTestBot(t)
+	type outcome struct {
+		method string
+		err    error
+		result json.RawMessage
+	}
+	var mu sync.Mutex
+	var outcomes []outcome
+	bot.AfterResponse(func(ctx context.Context, call *tgx.APICall, result json.RawMessage, err error, duration time.Duration) {
+		if duration <= 0 {
+			t.Errorf("%s took %v", call.Method, duration)
+		}
+		mu.Lock()
+		outcomes = append(outcomes, outcome{call.Method, err, result})
+		mu.Unlock()
+	})
+	srv.Queue("sendDocument", tgxtest.BadRequest("file is empty"))
+
+	if err := bot.SendMessage(42, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	err := bot.SendDocumentFile(&tgx.SendDocumentRequest{
+		BaseMediaRequest: tgx.BaseMediaRequest{ChatId: 42},
+		Document:         tgx.FileFromBytes("report.txt", []byte("report")),
+	})
+	if err == nil {
+		t.Fatal("upload succeeded")
+	}
+
+	if len(outcomes) != 2 {
+		t.Fatalf("got %d outcomes", len(outcomes))
+	}
+	if outcomes[0].method != "sendMessage" || outcomes[0].err != nil || len(outcomes[0].result) == 0 {
+		t.Errorf("json call outcome = %+v", outcomes[0])
+	}
+	// multipart uploads go through the same hooks
+	if outcomes[1].method != "sendDocument" || outcomes[1].err == nil {
+		t.Errorf("multipart call outcome = %+v", outcomes[1])
+	}
+}
+
+func TestAPIMiddlewareAnswersCall(t *testing.T) {
+	bot, srv := newTestBot(t)
+	bot.UseAPIMiddleware(func(next tgx.APIFunc) tgx.APIFunc {
+		return func(ctx context.Context, call *tgx.APICall) (json.RawMessage, error) {
+			return json.RawMessage(`{"id":1,"is_bot":true,"first_name":"stub","username":"stub_bot"}`), nil
+		}
+	})
+
+	me, err := bot.GetMe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me.Username != "stub_bot" || len(srv.Calls()) != 0 {
+		t.Errorf("me = %+v, %d calls sent", me, len(srv.Calls()))
+	}
+}
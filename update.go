@@ -0,0 +1,53 @@
+package tgx
+
+import "github.com/harshyadavone/tgx/models"
+
+// updateChat returns the chat an update belongs to, if any.
+func updateChat(update *models.Update) *models.Chat {
+	switch {
+	case update.Message != nil:
+		return &update.Message.Chat
+	case update.EditedMessage != nil:
+		return &update.EditedMessage.Chat
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return &update.CallbackQuery.Message.Chat
+	default:
+		return nil
+	}
+}
+
+// updateUser returns the user who triggered an update, if any.
+func updateUser(update *models.Update) *models.User {
+	var user *models.User
+	switch {
+	case update.Message != nil:
+		user = &update.Message.From
+	case update.EditedMessage != nil:
+		user = &update.EditedMessage.From
+	case update.CallbackQuery != nil:
+		user = &update.CallbackQuery.From
+	case update.InlineQuery != nil:
+		user = &update.InlineQuery.From
+	}
+	// channel posts have no sender
+	if user == nil || user.Id == 0 {
+		return nil
+	}
+	return user
+}
+
+// updateChatID returns the id of the chat an update belongs to, if any.
+func updateChatID(update *models.Update) (int64, bool) {
+	if chat := updateChat(update); chat != nil {
+		return chat.Id, true
+	}
+	return 0, false
+}
+
+// updateUserID returns the id of the user who triggered an update, if any.
+func updateUserID(update *models.Update) (int64, bool) {
+	if user := updateUser(update); user != nil {
+		return user.Id, true
+	}
+	return 0, false
+}
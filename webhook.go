@@ -0,0 +1,298 @@
+package tgx
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// secretTokenHeader carries the secret_token given to setWebhook on every
+// webhook request
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+var secretTokenRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+type WebhookInfo struct {
+	URL                          string   `json:"url"`
+	HasCustomCertificate         bool     `json:"has_custom_certificate"`
+	PendingUpdateCount           int      `json:"pending_update_count"`
+	IPAddress                    string   `json:"ip_address,omitempty"`
+	LastErrorDate                int64    `json:"last_error_date,omitempty"`
+	LastErrorMessage             string   `json:"last_error_message,omitempty"`
+	LastSynchronizationErrorDate int64    `json:"last_synchronization_error_date,omitempty"`
+	MaxConnections               int      `json:"max_connections,omitempty"`
+	AllowedUpdates               []string `json:"allowed_updates,omitempty"`
+}
+
+// UseSecretToken makes HandleWebhook reject requests that don't carry the
+// given secret. SetWebhook registers it with Telegram; replicas that don't
+// call SetWebhook themselves only need this.
+func (b *Bot) UseSecretToken(secret string) {
+	b.secretToken.Store(secret)
+}
+
+func (b *Bot) secret() string {
+	secret, _ := b.secretToken.Load().(string)
+	return secret
+}
+
+func (b *Bot) SetWebhook() error {
+	return b.SetWebhookWithOpts(&SetWebhookRequest{})
+}
+
+// SetWebhookWithOpts registers the webhook, URL defaults to the one given to
+// NewBot and SecretToken to the one set with UseSecretToken.
+func (b *Bot) SetWebhookWithOpts(req *SetWebhookRequest) error {
+	url := req.URL
+	if url == "" {
+		url = b.webhookURL
+	}
+	if url == "" {
+		return &BotError{
+			Code:    http.StatusBadRequest,
+			Message: "webhook url can't be empty",
+		}
+	}
+
+	secret := req.SecretToken
+	if secret == "" {
+		secret = b.secret()
+	}
+	if secret != "" && !secretTokenRegex.MatchString(secret) {
+		return &BotError{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid secret token",
+			Err:     fmt.Errorf("secret token must be 1-256 characters of A-Z, a-z, 0-9, _ and -"),
+		}
+	}
+
+	if req.MaxConnections != 0 && (req.MaxConnections < 1 || req.MaxConnections > 100) {
+		return &BotError{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid max connections",
+			Err:     fmt.Errorf("max connections must be between 1 and 100, got %d", req.MaxConnections),
+		}
+	}
+
+	params := map[string]interface{}{
+		"url": url,
+	}
+
+	if req.Certificate != nil {
+		params["certificate"] = req.Certificate
+	}
+	if req.IPAddress != "" {
+		params["ip_address"] = req.IPAddress
+	}
+	if req.MaxConnections != 0 {
+		params["max_connections"] = req.MaxConnections
+	}
+	if req.AllowedUpdates != nil {
+		params["allowed_updates"] = req.AllowedUpdates
+	}
+	if req.DropPendingUpdates {
+		params["drop_pending_updates"] = true
+	}
+	if secret != "" {
+		params["secret_token"] = secret
+	}
+
+	if err := b.makeAPIRequest("setWebhook", params); err != nil {
+		return err
+	}
+	b.secretToken.Store(secret)
+	return nil
+}
+
+func (b *Bot) DeleteWebhook() error {
+	return b.DeleteWebhookWithOpts(&DeleteWebhookRequest{})
+}
+
+func (b *Bot) DeleteWebhookWithOpts(req *DeleteWebhookRequest) error {
+	params := map[string]interface{}{}
+	if req.DropPendingUpdates {
+		params["drop_pending_updates"] = true
+	}
+	return b.makeAPIRequest("deleteWebhook", params)
+}
+
+// verifySecretToken reports whether the request carries the configured
+// secret, always true when none is configured
+func (b *Bot) verifySecretToken(r *http.Request) bool {
+	secret := b.secret()
+	if secret == "" {
+		return true
+	}
+	got := r.Header.Get(secretTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
+
+func (b *Bot) GetWebhookInfo() (*WebhookInfo, error) {
+	result, err := b.makeAPIRequestWithResult("getWebhookInfo", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhookInfo WebhookInfo
+	if err := json.Unmarshal(result, &webhookInfo); err != nil {
+		return nil, &BotError{
+			Code:    http.StatusBadRequest,
+			Message: "failed to decode webhook info",
+			Err:     err,
+		}
+	}
+	return &webhookInfo, nil
+}
+
+func (b *Bot) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		b.logger.Error("Invalid HTTP method", "method", r.Method)
+		http.Error(w, "Only POST requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !b.verifySecretToken(r) {
+		b.logger.Warn("Rejected webhook request with invalid secret token", "remote_addr", r.RemoteAddr)
+		http.Error(w, "Invalid secret token", http.StatusUnauthorized)
+		return
+	}
+
+	update, werr := b.decodeUpdate(w, r)
+	if werr != nil {
+		b.reportWebhookError(werr)
+		http.Error(w, werr.Message, werr.Code)
+		return
+	}
+
+	if b.isDuplicate(r.Context(), update) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if b.pool != nil {
+		switch b.pool.submit(r.Context(), update) {
+		case submitRejected:
+			// Telegram retries the update, it must not count as seen
+			b.forgetUpdate(r.Context(), update)
+			http.Error(w, "Update queue is full", http.StatusServiceUnavailable)
+			return
+		case submitDropped:
+			b.logger.Warn("Update queue is full, dropped update", "update_id", update.UpdateId)
+		}
+		b.metrics.QueueDepth(b.pool.queueLength())
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Telegram may drop the connection while handlers still run, keep the
+	// request's values for tracing but not its cancellation
+	ctx := context.WithoutCancel(r.Context())
+	reply := b.newWebhookReply(ctx)
+	b.processUpdate(ctx, update, reply)
+	reply.write(w)
+}
+
+// DefaultMaxWebhookBodySize is the default limit of webhook request bodies.
+const DefaultMaxWebhookBodySize = 1 << 20
+
+// WebhookError describes a webhook request that couldn't be turned into an
+// update.
+type WebhookError struct {
+	Code     int // HTTP status sent back to Telegram
+	Message  string
+	UpdateID int // 0 when the update id couldn't be decoded either
+	Err      error
+}
+
+func (e *WebhookError) Error() string {
+	if e.UpdateID != 0 {
+		return fmt.Sprintf("%s (update %d): %v", e.Message, e.UpdateID, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *WebhookError) Unwrap() error {
+	return e.Err
+}
+
+// UseMaxWebhookBodySize limits the size of webhook request bodies, larger
+// requests are answered with 413.
+func (b *Bot) UseMaxWebhookBodySize(size int64) {
+	b.maxBodySize = size
+}
+
+// OnWebhookError sets the handler called when a webhook request is rejected
+// because its body can't be read or decoded. By default such errors are
+// logged.
+func (b *Bot) OnWebhookError(handler func(err *WebhookError)) {
+	b.webhookErrorHandler = handler
+}
+
+func (b *Bot) reportWebhookError(err *WebhookError) {
+	if b.webhookErrorHandler != nil {
+		b.webhookErrorHandler(err)
+		return
+	}
+	b.logger.Error("Webhook request rejected", "status", err.Code, "update_id", err.UpdateID, "error", err.Err)
+}
+
+func (b *Bot) decodeUpdate(w http.ResponseWriter, r *http.Request) (*models.Update, *WebhookError) {
+	defer r.Body.Close()
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/json" {
+			return nil, &WebhookError{
+				Code:    http.StatusUnsupportedMediaType,
+				Message: "Content type must be application/json",
+				Err:     fmt.Errorf("unsupported content type %q", contentType),
+			}
+		}
+	}
+
+	maxSize := b.maxBodySize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxWebhookBodySize
+	}
+
+	// the raw body is kept to recover the update id when decoding fails
+	var raw bytes.Buffer
+	body := io.TeeReader(http.MaxBytesReader(w, r.Body, maxSize), &raw)
+
+	var update models.Update
+	err := json.NewDecoder(body).Decode(&update)
+	if err == nil {
+		return &update, nil
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return nil, &WebhookError{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: "Request body too large",
+			Err:     err,
+		}
+	}
+
+	werr := &WebhookError{
+		Code:    http.StatusBadRequest,
+		Message: "Failed to decode update",
+		Err:     err,
+	}
+	var partial struct {
+		UpdateId int `json:"update_id"`
+	}
+	if json.Unmarshal(raw.Bytes(), &partial) == nil {
+		werr.UpdateID = partial.UpdateId
+	}
+	return nil, werr
+}
@@ -0,0 +1,171 @@
+package tgx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// UseWebhookReplies lets the first API call a handler makes be returned in
+// the webhook response instead of sent as a separate request, saving a round
+// trip. It only applies to updates handled synchronously by HandleWebhook,
+// not with the worker pool.
+//
+// The held call's result is unavailable: the context method making it
+// returns nil right away, and Telegram doesn't report whether the call
+// succeeded, so its errors are lost. Later calls made for the same update
+// send the held call first so they can't reach Telegram before it. Calls
+// made directly on the Bot don't belong to an update and aren't ordered
+// after held calls.
+func (b *Bot) UseWebhookReplies(enabled bool) {
+	b.webhookReplies = enabled
+}
+
+// webhookReply holds the call that will be written to the webhook response.
+type webhookReply struct {
+	bot *Bot
+	ctx context.Context
+
+	mu      sync.Mutex
+	done    bool // no more calls can be held, the handler returned or made a second call
+	pending bool
+	method  string
+	params  map[string]interface{}
+	sent    chan struct{} // closed once the held call was sent or written
+}
+
+func (b *Bot) newWebhookReply(ctx context.Context) *webhookReply {
+	if !b.webhookReplies || b.pool != nil {
+		return nil
+	}
+	return &webhookReply{bot: b, ctx: ctx}
+}
+
+// hold keeps the call for the webhook response, it reports false when the
+// call must be sent normally. The held call is sent before any later one,
+// which waits for it, so calls keep their order.
+func (r *webhookReply) hold(method string, params map[string]interface{}) bool {
+	if r == nil {
+		return false
+	}
+
+	r.mu.Lock()
+	switch {
+	case r.pending:
+		r.sendPending()
+		return false
+	case r.done:
+		sent := r.sent
+		r.mu.Unlock()
+		if sent != nil {
+			<-sent
+		}
+		return false
+	case hasUploads(params):
+		// uploads need multipart requests which webhook responses can't
+		// carry
+		r.mu.Unlock()
+		return false
+	}
+
+	r.pending = true
+	r.method = method
+	r.params = params
+	r.sent = make(chan struct{})
+	r.mu.Unlock()
+	return true
+}
+
+// release sends the held call, if any, and stops holding calls. It is used
+// before requests that don't go through hold so calls keep their order.
+func (r *webhookReply) release() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	if r.pending {
+		r.sendPending()
+		return
+	}
+	r.done = true
+	sent := r.sent
+	r.mu.Unlock()
+	if sent != nil {
+		<-sent
+	}
+}
+
+// sendPending sends the held call. It is called with r.mu held and unlocks
+// it before the request, calls arriving meanwhile wait for r.sent.
+func (r *webhookReply) sendPending() {
+	r.done = true
+	r.pending = false
+	method, params, sent := r.method, r.params, r.sent
+	r.mu.Unlock()
+
+	if _, err := r.bot.callAPI(r.ctx, method, params); err != nil {
+		r.bot.logger.Error("Failed to send held call", "method", method, "error", err)
+	}
+	close(sent)
+}
+
+// write ends the handler's chance to hold calls and writes the response.
+func (r *webhookReply) write(w http.ResponseWriter) {
+	if r == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	r.mu.Lock()
+	r.done = true
+	if !r.pending {
+		r.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	r.pending = false
+	method, params, sent := r.method, r.params, r.sent
+	r.mu.Unlock()
+	defer close(sent)
+
+	body := make(map[string]interface{}, len(params)+1)
+	for key, val := range params {
+		body[key] = val
+	}
+	body["method"] = method
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		r.bot.logger.Error("Failed to encode webhook reply, sending it normally", "method", method, "error", err)
+		if _, err := r.bot.callAPI(r.ctx, method, params); err != nil {
+			r.bot.logger.Error("Failed to send call", "method", method, "error", err)
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	r.bot.recordCall(method, params, nil, nil, true)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+type webhookReplyKey struct{}
+
+// withWebhookReply returns a context carrying the reply of the update it
+// belongs to.
+func withWebhookReply(ctx context.Context, r *webhookReply) context.Context {
+	if r == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, webhookReplyKey{}, r)
+}
+
+// webhookReplyFrom returns the reply of the update ctx belongs to, nil if
+// there is none.
+func webhookReplyFrom(ctx context.Context) *webhookReply {
+	r, _ := ctx.Value(webhookReplyKey{}).(*webhookReply)
+	return r
+}
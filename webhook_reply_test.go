@@ -0,0 +1,124 @@
+package tgx_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+	"github.com/harshyadavone/tgx/pkg/tgxtest"
+)
+
+func newWebhookReplyBot(t *testing.T) (*tgx.Bot, *tgxtest.Server) {
+	t.Helper()
+	bot, srv := newTestBot(t)
+	srv.SetDirect(false)
+	bot.UseWebhookReplies(true)
+	return bot, srv
+}
+
+func TestWebhookReplyHoldsFirstCall(t *testing.T) {
+	bot, srv := newWebhookReplyBot(t)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		return ctx.Reply("hello")
+	})
+
+	if _, err := srv.SendText(tgxtest.User(1), tgxtest.PrivateChat(1), "/start"); err != nil {
+		t.Fatal(err)
+	}
+	calls := srv.Calls()
+	if len(calls) != 1 || !calls[0].Webhook || calls[0].String("text") != "hello" {
+		t.Fatalf("calls = %+v, want the reply in the webhook response", calls)
+	}
+}
+
+// Calls after the held one send it first.
+func TestWebhookReplyKeepsOrder(t *testing.T) {
+	bot, srv := newWebhookReplyBot(t)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		if err := ctx.Reply("first"); err != nil {
+			return err
+		}
+		if err := ctx.Reply("second"); err != nil {
+			return err
+		}
+		return ctx.Reply("third")
+	})
+
+	if _, err := srv.SendText(tgxtest.User(1), tgxtest.PrivateChat(1), "/start"); err != nil {
+		t.Fatal(err)
+	}
+	calls := srv.Calls()
+	var texts []string
+	for _, c := range calls {
+		if c.Webhook {
+			t.Errorf("%q returned in the webhook response after later calls were sent", c.String("text"))
+		}
+		texts = append(texts, c.String("text"))
+	}
+	if len(texts) != 3 || texts[0] != "first" || texts[1] != "second" || texts[2] != "third" {
+		t.Errorf("texts = %q, want first, second, third", texts)
+	}
+}
+
+// Calls made on the bot with the update's context send the held call first.
+func TestWebhookReplyKeepsOrderWithContext(t *testing.T) {
+	bot, srv := newWebhookReplyBot(t)
+	bot.OnCommand("start", func(ctx *tgx.Context) error {
+		if err := ctx.Reply("first"); err != nil {
+			return err
+		}
+		return bot.WithContext(ctx.Context()).SendMessage(ctx.ChatID, "second")
+	})
+
+	if _, err := srv.SendText(tgxtest.User(1), tgxtest.PrivateChat(1), "/start"); err != nil {
+		t.Fatal(err)
+	}
+	calls := srv.Calls()
+	if len(calls) != 2 || calls[0].Webhook || calls[0].String("text") != "first" || calls[1].String("text") != "second" {
+		t.Errorf("calls = %+v, want first sent before second", calls)
+	}
+}
+
+// Calls made for one update leave the calls held for others alone.
+func TestWebhookReplyConcurrentUpdates(t *testing.T) {
+	bot, srv := newWebhookReplyBot(t)
+	aHeld, bDone := make(chan struct{}), make(chan struct{})
+	bot.OnCommand("a", func(ctx *tgx.Context) error {
+		if err := ctx.Reply("a"); err != nil {
+			return err
+		}
+		close(aHeld)
+		<-bDone
+		return nil
+	})
+	bot.OnCommand("b", func(ctx *tgx.Context) error {
+		defer close(bDone)
+		<-aHeld
+		if err := ctx.Reply("b"); err != nil {
+			return err
+		}
+		return bot.SendMessage(99, "direct")
+	})
+
+	var wg sync.WaitGroup
+	for i, text := range []string{"/a", "/b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := srv.SendText(tgxtest.User(int64(i+1)), tgxtest.PrivateChat(int64(i+1)), text); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	webhook := make(map[string]bool)
+	for _, c := range srv.Calls() {
+		webhook[c.String("text")] = c.Webhook
+	}
+	want := map[string]bool{"a": true, "b": true, "direct": false}
+	if !reflect.DeepEqual(webhook, want) {
+		t.Errorf("returned in the webhook response = %v, want %v", webhook, want)
+	}
+}
@@ -0,0 +1,139 @@
+package tgx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/harshyadavone/tgx"
+)
+
+const testUpdate = `{"update_id":1,"message":{"message_id":1,"chat":{"id":1,"type":"private"},"from":{"id":1,"first_name":"A"},"text":"hi"}}`
+
+func webhookRequest(secret string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(testUpdate))
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+	}
+	return req
+}
+
+func TestWebhookSecretToken(t *testing.T) {
+	bot, _ := newTestBot(t)
+	handled := 0
+	bot.OnMessage("Text", func(ctx *tgx.Context) error {
+		handled++
+		return nil
+	})
+	bot.UseSecretToken("s3cret")
+
+	for _, secret := range []string{"", "wrong", "s3cret-longer"} {
+		rec := httptest.NewRecorder()
+		bot.HandleWebhook(rec, webhookRequest(secret))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("secret %q: status = %d, want 401", secret, rec.Code)
+		}
+	}
+	if handled != 0 {
+		t.Fatalf("rejected updates were handled")
+	}
+
+	rec := httptest.NewRecorder()
+	bot.HandleWebhook(rec, webhookRequest("s3cret"))
+	if rec.Code != http.StatusOK || handled != 1 {
+		t.Errorf("status = %d, handled = %d", rec.Code, handled)
+	}
+}
+
+// SetWebhookWithOpts registers the secret with Telegram and starts
+// checking it.
+func TestSetWebhookSecretToken(t *testing.T) {
+	bot, srv := newTestBot(t)
+	err := bot.SetWebhookWithOpts(&tgx.SetWebhookRequest{URL: "https://example.com/hook", SecretToken: "from-opts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.AssertCalled(t, "setWebhook").String("secret_token"); got != "from-opts" {
+		t.Errorf("secret_token = %q", got)
+	}
+
+	rec := httptest.NewRecorder()
+	bot.HandleWebhook(rec, webhookRequest(""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+
+	err = bot.SetWebhookWithOpts(&tgx.SetWebhookRequest{URL: "https://example.com/hook", SecretToken: "not valid!"})
+	if err == nil {
+		t.Error("invalid secret accepted")
+	}
+}
+
+// The secret can change while webhook requests are served.
+func TestSecretTokenConcurrentUpdate(t *testing.T) {
+	bot, _ := newTestBot(t)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			bot.HandleWebhook(httptest.NewRecorder(), webhookRequest("a"))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			bot.SetWebhookWithOpts(&tgx.SetWebhookRequest{URL: "https://example.com/hook", SecretToken: "a"})
+		}
+	}()
+	wg.Wait()
+}
+
+func TestWebhookRejectsBadRequests(t *testing.T) {
+	bot, _ := newTestBot(t)
+	bot.UseMaxWebhookBodySize(64)
+	var reported []*tgx.WebhookError
+	bot.OnWebhookError(func(err *tgx.WebhookError) {
+		reported = append(reported, err)
+	})
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		code        int
+		updateID    int
+	}{
+		{"too large", `{"update_id":7,"message":{"text":"` + strings.Repeat("a", 100) + `"}}`, "application/json", http.StatusRequestEntityTooLarge, 0},
+		{"wrong content type", `{"update_id":1}`, "text/plain", http.StatusUnsupportedMediaType, 0},
+		{"invalid field", `{"update_id":5,"message":"text"}`, "application/json; charset=utf-8", http.StatusBadRequest, 5},
+		{"not json", `update`, "application/json", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		rec := httptest.NewRecorder()
+		bot.HandleWebhook(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.code)
+		}
+	}
+
+	if len(reported) != len(tests) {
+		t.Fatalf("reported %d errors, want %d", len(reported), len(tests))
+	}
+	for i, tt := range tests {
+		if reported[i].Code != tt.code || reported[i].UpdateID != tt.updateID {
+			t.Errorf("%s: reported %+v", tt.name, reported[i])
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	bot.HandleWebhook(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,261 @@
+package tgx
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+// Backpressure decides what HandleWebhook does when the update queue is full.
+type Backpressure int
+
+const (
+	// BackpressureBlock waits for room in the queue, up to the request's
+	// lifetime.
+	BackpressureBlock Backpressure = iota
+	// BackpressureDrop acknowledges the update and discards it.
+	BackpressureDrop
+	// BackpressureReject answers 503 so Telegram delivers the update again
+	// later.
+	BackpressureReject
+)
+
+// ShardBy decides which updates must be processed in order.
+type ShardBy int
+
+const (
+	// ShardNone processes updates in any order on any worker.
+	ShardNone ShardBy = iota
+	// ShardByChat processes updates of a chat one at a time, in the order
+	// they were received, while different chats run in parallel. Updates
+	// without a chat fall back to the user.
+	ShardByChat
+	// ShardByUser does the same per user, falling back to the chat.
+	ShardByUser
+)
+
+type WorkerPoolOptions struct {
+	Workers      int // defaults to the number of CPUs
+	QueueSize    int // defaults to 100, split between workers when sharding
+	Backpressure Backpressure
+	ShardBy      ShardBy
+}
+
+type WorkerPoolStats struct {
+	Workers       int
+	QueueLength   int
+	QueueCapacity int
+	Active        int64 // updates being handled right now
+	Processed     uint64
+	Dropped       uint64
+	Rejected      uint64
+}
+
+type submitResult int
+
+const (
+	submitQueued submitResult = iota
+	submitDropped
+	submitRejected
+)
+
+type workerPool struct {
+	opts WorkerPoolOptions
+	// a single queue shared by all workers, or one queue per worker when
+	// sharding
+	queues []chan *models.Update
+	handle func(*models.Update)
+	next   atomic.Uint64
+
+	mu       sync.RWMutex
+	closed   bool
+	quit     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+
+	active    atomic.Int64
+	processed atomic.Uint64
+	dropped   atomic.Uint64
+	rejected  atomic.Uint64
+}
+
+// UseWorkerPool makes HandleWebhook acknowledge updates right away and
+// process them on a bounded pool of workers, so slow handlers don't make
+// Telegram time out and redeliver.
+func (b *Bot) UseWorkerPool(opts WorkerPoolOptions) {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 100
+	}
+	b.pool = newWorkerPool(opts, func(update *models.Update) {
+		b.metrics.QueueDepth(b.pool.queueLength())
+		b.ProcessUpdate(update)
+	})
+}
+
+// StopWorkerPool stops accepting updates and waits for the queued ones to be
+// processed, or for ctx to be done.
+func (b *Bot) StopWorkerPool(ctx context.Context) error {
+	if b.pool == nil {
+		return nil
+	}
+	return b.pool.stop(ctx)
+}
+
+func (b *Bot) WorkerPoolStats() WorkerPoolStats {
+	if b.pool == nil {
+		return WorkerPoolStats{}
+	}
+	return b.pool.stats()
+}
+
+func newWorkerPool(opts WorkerPoolOptions, handle func(*models.Update)) *workerPool {
+	p := &workerPool{
+		opts:   opts,
+		handle: handle,
+		quit:   make(chan struct{}),
+	}
+
+	if opts.ShardBy == ShardNone {
+		p.queues = []chan *models.Update{make(chan *models.Update, opts.QueueSize)}
+	} else {
+		size := max(opts.QueueSize/opts.Workers, 1)
+		for i := 0; i < opts.Workers; i++ {
+			p.queues = append(p.queues, make(chan *models.Update, size))
+		}
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		p.wg.Add(1)
+		go p.work(p.queues[i%len(p.queues)])
+	}
+	return p
+}
+
+func (p *workerPool) work(queue chan *models.Update) {
+	defer p.wg.Done()
+	for update := range queue {
+		p.active.Add(1)
+		p.handle(update)
+		p.active.Add(-1)
+		p.processed.Add(1)
+	}
+}
+
+func (p *workerPool) submit(ctx context.Context, update *models.Update) submitResult {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		p.rejected.Add(1)
+		return submitRejected
+	}
+	queue := p.queueFor(update)
+
+	if p.opts.Backpressure == BackpressureBlock {
+		select {
+		case queue <- update:
+			return submitQueued
+		case <-ctx.Done():
+		case <-p.quit:
+		}
+		p.rejected.Add(1)
+		return submitRejected
+	}
+
+	select {
+	case queue <- update:
+		return submitQueued
+	default:
+	}
+
+	if p.opts.Backpressure == BackpressureDrop {
+		p.dropped.Add(1)
+		return submitDropped
+	}
+	p.rejected.Add(1)
+	return submitRejected
+}
+
+// stop may be called more than once and concurrently, every call waits for
+// the workers.
+func (p *workerPool) stop(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		// wake blocked submitters before taking the write lock
+		close(p.quit)
+		p.mu.Lock()
+		p.closed = true
+		for _, queue := range p.queues {
+			close(queue)
+		}
+		p.mu.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// queueFor picks the queue of the worker owning the update's shard key
+func (p *workerPool) queueFor(update *models.Update) chan *models.Update {
+	if len(p.queues) == 1 {
+		return p.queues[0]
+	}
+
+	var key int64
+	var ok bool
+	switch p.opts.ShardBy {
+	case ShardByChat:
+		if key, ok = updateChatID(update); !ok {
+			key, ok = updateUserID(update)
+		}
+	case ShardByUser:
+		if key, ok = updateUserID(update); !ok {
+			key, ok = updateChatID(update)
+		}
+	}
+	if !ok {
+		// nothing to order by, spread the update over the workers
+		return p.queues[p.next.Add(1)%uint64(len(p.queues))]
+	}
+	return p.queues[uint64(key)%uint64(len(p.queues))]
+}
+
+func (p *workerPool) queueLength() int {
+	length := 0
+	for _, queue := range p.queues {
+		length += len(queue)
+	}
+	return length
+}
+
+func (p *workerPool) stats() WorkerPoolStats {
+	length, capacity := 0, 0
+	for _, queue := range p.queues {
+		length += len(queue)
+		capacity += cap(queue)
+	}
+	return WorkerPoolStats{
+		Workers:       p.opts.Workers,
+		QueueLength:   length,
+		QueueCapacity: capacity,
+		Active:        p.active.Load(),
+		Processed:     p.processed.Load(),
+		Dropped:       p.dropped.Load(),
+		Rejected:      p.rejected.Load(),
+	}
+}
@@ -0,0 +1,173 @@
+package tgx
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harshyadavone/tgx/models"
+)
+
+func chatUpdate(id int, chatID int64) *models.Update {
+	return &models.Update{UpdateId: id, Message: &models.Message{Chat: models.Chat{Id: chatID}}}
+}
+
+// stop waits for queued updates and rejects new ones.
+func TestWorkerPoolStop(t *testing.T) {
+	var mu sync.Mutex
+	var handled []int
+	p := newWorkerPool(WorkerPoolOptions{Workers: 2, QueueSize: 10}, func(u *models.Update) {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		handled = append(handled, u.UpdateId)
+		mu.Unlock()
+	})
+
+	for i := 1; i <= 10; i++ {
+		if got := p.submit(context.Background(), chatUpdate(i, 1)); got != submitQueued {
+			t.Fatalf("submit %d = %v", i, got)
+		}
+	}
+	if err := p.stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(handled) != 10 {
+		t.Errorf("handled %d updates before stop returned, want 10", len(handled))
+	}
+	if got := p.submit(context.Background(), chatUpdate(11, 1)); got != submitRejected {
+		t.Errorf("submit after stop = %v, want rejected", got)
+	}
+	if stats := p.stats(); stats.Processed != 10 || stats.Rejected != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestWorkerPoolConcurrentStop(t *testing.T) {
+	p := newWorkerPool(WorkerPoolOptions{Workers: 4, QueueSize: 10}, func(*models.Update) {})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.stop(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// stop gives up waiting when ctx is done, and wakes blocked submitters.
+func TestWorkerPoolStopTimeout(t *testing.T) {
+	release := make(chan struct{})
+	p := newWorkerPool(WorkerPoolOptions{Workers: 1, QueueSize: 1}, func(*models.Update) { <-release })
+	defer close(release)
+
+	p.submit(context.Background(), chatUpdate(1, 1)) // handled, blocks the worker
+	p.submit(context.Background(), chatUpdate(2, 1)) // fills the queue
+
+	blocked := make(chan submitResult)
+	go func() { blocked <- p.submit(context.Background(), chatUpdate(3, 1)) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.stop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("stop = %v, want deadline exceeded", err)
+	}
+	select {
+	case got := <-blocked:
+		if got != submitRejected {
+			t.Errorf("blocked submit = %v, want rejected", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked submit not woken by stop")
+	}
+}
+
+func TestWorkerPoolBackpressure(t *testing.T) {
+	for _, tt := range []struct {
+		backpressure Backpressure
+		want         submitResult
+	}{
+		{BackpressureDrop, submitDropped},
+		{BackpressureReject, submitRejected},
+	} {
+		started, release := make(chan struct{}, 2), make(chan struct{})
+		p := newWorkerPool(WorkerPoolOptions{Workers: 1, QueueSize: 1, Backpressure: tt.backpressure}, func(*models.Update) {
+			started <- struct{}{}
+			<-release
+		})
+
+		p.submit(context.Background(), chatUpdate(1, 1))
+		<-started // the worker is busy
+		p.submit(context.Background(), chatUpdate(2, 1))
+		if got := p.submit(context.Background(), chatUpdate(3, 1)); got != tt.want {
+			t.Errorf("backpressure %d: submit = %v, want %v", tt.backpressure, got, tt.want)
+		}
+		close(release)
+		p.stop(context.Background())
+	}
+}
+
+// Sharded updates of a chat are handled one at a time in the order they were
+// submitted.
+func TestWorkerPoolShardOrder(t *testing.T) {
+	var mu sync.Mutex
+	handled := make(map[int64][]int)
+	running := make(map[int64]bool)
+	p := newWorkerPool(WorkerPoolOptions{Workers: 4, QueueSize: 400, ShardBy: ShardByChat}, func(u *models.Update) {
+		chatID := u.Message.Chat.Id
+		mu.Lock()
+		if running[chatID] {
+			t.Errorf("chat %d handled concurrently", chatID)
+		}
+		running[chatID] = true
+		mu.Unlock()
+
+		time.Sleep(time.Duration(u.UpdateId%3) * time.Millisecond)
+
+		mu.Lock()
+		running[chatID] = false
+		handled[chatID] = append(handled[chatID], u.UpdateId)
+		mu.Unlock()
+	})
+
+	for i := 1; i <= 200; i++ {
+		p.submit(context.Background(), chatUpdate(i, int64(i%7)))
+	}
+	if err := p.stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for chatID, ids := range handled {
+		total += len(ids)
+		for i := 1; i < len(ids); i++ {
+			if ids[i] < ids[i-1] {
+				t.Errorf("chat %d: update %d handled before %d", chatID, ids[i-1], ids[i])
+			}
+		}
+	}
+	if total != 200 {
+		t.Errorf("handled %d updates, want 200", total)
+	}
+}
+
+func TestWorkerPoolShardKey(t *testing.T) {
+	p := &workerPool{opts: WorkerPoolOptions{ShardBy: ShardByUser}, queues: make([]chan *models.Update, 4)}
+	for i := range p.queues {
+		p.queues[i] = make(chan *models.Update)
+	}
+
+	fromUser := func(userID, chatID int64) *models.Update {
+		return &models.Update{Message: &models.Message{From: models.User{Id: userID}, Chat: models.Chat{Id: chatID}}}
+	}
+	if p.queueFor(fromUser(5, 1)) != p.queueFor(fromUser(5, 2)) {
+		t.Error("updates of a user in different chats went to different workers")
+	}
+	query := &models.Update{CallbackQuery: &models.CallbackQuery{From: models.User{Id: 5}}}
+	if p.queueFor(query) != p.queueFor(fromUser(5, 3)) {
+		t.Error("callback query of the user went to another worker")
+	}
+}